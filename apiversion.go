@@ -0,0 +1,71 @@
+package shout
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//APIVersionHeader is the response header that SHOUT! reports the version of
+// its API in
+const APIVersionHeader = "X-Shout-Api-Version"
+
+//withVersion adds the API version as a parameter of each media type in the
+// given Accept header value
+func withVersion(accept, version string) string {
+	types := strings.Split(accept, ",")
+	for i, t := range types {
+		types[i] = strings.TrimSpace(t) + "; version=" + version
+	}
+
+	return strings.Join(types, ", ")
+}
+
+//responseVersion returns the API version that the response says it was
+// made with, from APIVersionHeader or else the version parameter of its
+// Content-Type. It returns an empty string if there is neither.
+func responseVersion(header http.Header) string {
+	if v := strings.TrimSpace(header.Get(APIVersionHeader)); v != "" {
+		return v
+	}
+
+	_, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	return params["version"]
+}
+
+//apiVersionTracker records the API version from the most recent response that
+// reported one
+type apiVersionTracker struct {
+	lock    sync.Mutex
+	version string
+}
+
+func (a *apiVersionTracker) update(header http.Header) {
+	v := responseVersion(header)
+	if v == "" {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.version = v
+}
+
+//ServerAPIVersion returns the API version that SHOUT! reported in the most
+// recent response that included one. It returns false if no response has. The
+// version is only tracked for clients created with NewClient, and is shared
+// with clones of the client.
+func (c *Client) ServerAPIVersion() (string, bool) {
+	if c.apiVersions == nil {
+		return "", false
+	}
+
+	c.apiVersions.lock.Lock()
+	defer c.apiVersions.lock.Unlock()
+	return c.apiVersions.version, c.apiVersions.version != ""
+}
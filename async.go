@@ -0,0 +1,169 @@
+package shout
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultAsyncFlushInterval = time.Second
+	defaultAsyncMaxBatchSize  = 100
+	defaultAsyncQueueSize     = 1000
+)
+
+//AsyncConfig configures an AsyncSender. Zero values are replaced by defaults.
+type AsyncConfig struct {
+	//FlushInterval is the longest that an event waits in the queue before it
+	// is sent. Defaults to 1s
+	FlushInterval time.Duration
+	//MaxBatchSize is the most events that are sent in one flush. A flush
+	// happens early if this many events are waiting. Defaults to 100
+	MaxBatchSize int
+	//QueueSize is the most events that can wait to be sent. Events enqueued
+	// while the queue is full are dropped. Defaults to 1000
+	QueueSize int
+	//OnDrop, if non-nil, is called with each event that is dropped because the
+	// queue was full or the sender was closed
+	OnDrop func(EventIn)
+	//OnError, if non-nil, is called with the error from each flush that fails
+	// to send some of its events. The error is usually a *BatchError.
+	OnError func(error)
+}
+
+//AsyncSender queues events and posts them in batches from a background
+// goroutine, so that enqueueing never waits on SHOUT!. Create one with
+// NewAsyncSender, and stop it with Close.
+type AsyncSender struct {
+	client *Client
+	config AsyncConfig
+
+	queue   chan EventIn
+	lock    sync.RWMutex
+	closed  bool
+	dropped uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+//NewAsyncSender returns an AsyncSender that posts events with the given
+// client, and starts its background goroutine
+func NewAsyncSender(client *Client, config AsyncConfig) *AsyncSender {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultAsyncFlushInterval
+	}
+
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = defaultAsyncMaxBatchSize
+	}
+
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultAsyncQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &AsyncSender{
+		client: client,
+		config: config,
+		queue:  make(chan EventIn, config.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go s.run()
+	return s
+}
+
+//Enqueue adds the event to the queue to be sent. It returns false if the
+// event was dropped instead, because the queue is full or the sender has been
+// closed.
+func (s *AsyncSender) Enqueue(e EventIn) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if !s.closed {
+		select {
+		case s.queue <- e:
+			return true
+		default:
+		}
+	}
+
+	atomic.AddUint64(&s.dropped, 1)
+	if s.config.OnDrop != nil {
+		s.config.OnDrop(e)
+	}
+
+	return false
+}
+
+//Dropped returns the number of events that have been dropped so far
+func (s *AsyncSender) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+//Close stops accepting events and waits for those already queued to be sent.
+// If the context is done first, sending is abandoned and the context's error
+// is returned. Closing a sender that is already closed waits in the same way,
+// and is otherwise harmless.
+func (s *AsyncSender) Close(ctx context.Context) error {
+	s.lock.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.lock.Unlock()
+
+	select {
+	case <-s.done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+		return ctx.Err()
+	}
+}
+
+func (s *AsyncSender) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]EventIn, 0, s.config.MaxBatchSize)
+	for {
+		select {
+		case e, ok := <-s.queue:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+
+			batch = append(batch, e)
+			if len(batch) >= s.config.MaxBatchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (s *AsyncSender) flush(batch []EventIn) {
+	if len(batch) == 0 {
+		return
+	}
+
+	_, err := s.client.PostEventsCtx(s.ctx, batch)
+	if err != nil && s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
@@ -0,0 +1,114 @@
+package shout
+
+import (
+	"context"
+	"sync"
+)
+
+//batchConcurrency is the most requests that will be in flight at once when
+// posting a batch
+const batchConcurrency = 8
+
+//PostEvents posts each of the given events to SHOUT!. SHOUT! has no bulk
+// endpoint, so the events are sent as individual requests, a few at a time.
+// The states of the topics whose events were posted successfully are returned
+// in the order that their events were given. If any events fail to post, the
+// returned error is a *BatchError identifying them.
+func (c *Client) PostEvents(events []EventIn) ([]StateOut, error) {
+	return c.PostEventsCtx(c.context(), events)
+}
+
+//PostEventsCtx is PostEvents, but the requests are bound to the given context
+func (c *Client) PostEventsCtx(ctx context.Context, events []EventIn) ([]StateOut, error) {
+	states := make([]*StateOut, len(events))
+	errs := make([]error, len(events))
+
+	runBatch(len(events), func(i int) {
+		states[i], errs[i] = c.PostEventCtx(ctx, events[i])
+	})
+
+	ret := make([]StateOut, 0, len(events))
+	batchErr := &BatchError{}
+	for i := range events {
+		if errs[i] != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{
+				Index: i,
+				Topic: events[i].Topic,
+				Err:   errs[i],
+			})
+			continue
+		}
+
+		ret = append(ret, *states[i])
+	}
+
+	if len(batchErr.Failures) > 0 {
+		return ret, batchErr
+	}
+
+	return ret, nil
+}
+
+//runBatch calls fn with each index from 0 to n-1, with at most
+// batchConcurrency calls running at once, and waits for all of them to return
+func runBatch(n int, fn func(i int)) {
+	indices := make(chan int)
+	wg := sync.WaitGroup{}
+
+	workers := batchConcurrency
+	if n < workers {
+		workers = n
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+
+	close(indices)
+	wg.Wait()
+}
+
+//PostAnnouncements posts each of the given announcements to SHOUT!, as
+// individual requests sent a few at a time, like PostEvents. If any
+// announcements fail to post, the returned error is a *BatchError identifying
+// them; the rest were posted successfully.
+func (c *Client) PostAnnouncements(announcements []AnnouncementIn) error {
+	return c.PostAnnouncementsCtx(c.context(), announcements)
+}
+
+//PostAnnouncementsCtx is PostAnnouncements, but the requests are bound to the
+// given context
+func (c *Client) PostAnnouncementsCtx(ctx context.Context, announcements []AnnouncementIn) error {
+	errs := make([]error, len(announcements))
+
+	runBatch(len(announcements), func(i int) {
+		errs[i] = c.PostAnnouncementCtx(ctx, announcements[i])
+	})
+
+	batchErr := &BatchError{}
+	for i, err := range errs {
+		if err != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{
+				Index: i,
+				Topic: announcements[i].Topic,
+				Err:   err,
+			})
+		}
+	}
+
+	if len(batchErr.Failures) > 0 {
+		return batchErr
+	}
+
+	return nil
+}
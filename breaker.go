@@ -0,0 +1,68 @@
+package shout
+
+import (
+	"sync"
+	"time"
+)
+
+//circuitBreaker fails requests fast after too many consecutive failures. Once
+// the cooldown passes, a single probe request is let through: if it succeeds
+// the circuit closes again, and if it fails the cooldown starts over.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	lock     sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+//allow returns true if a request may be sent
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.open {
+		return true
+	}
+
+	if b.probing || now.Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	b.probing = true
+	return true
+}
+
+//record notes the outcome of a request that allow let through
+func (b *circuitBreaker) record(failed bool, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.open = false
+		return
+	}
+
+	b.failures++
+	if b.open || b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = now
+	}
+}
+
+//abandon notes that a request that allow let through ended without a
+// meaningful outcome, so that another probe can be made
+func (b *circuitBreaker) abandon() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.probing = false
+}
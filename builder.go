@@ -0,0 +1,80 @@
+package shout
+
+import "time"
+
+//EventBuilder builds an EventIn a field at a time, which can be tidier than a
+// struct literal when fields are set conditionally. Create one with NewEvent.
+type EventBuilder struct {
+	e EventIn
+}
+
+//NewEvent returns an EventBuilder for an event on the given topic. The event
+// starts out with OK set to false.
+func NewEvent(topic string) *EventBuilder {
+	return &EventBuilder{e: EventIn{Topic: topic}}
+}
+
+//Message sets the message of the event
+func (b *EventBuilder) Message(message string) *EventBuilder {
+	b.e.Message = message
+	return b
+}
+
+//Link sets the link of the event
+func (b *EventBuilder) Link(link string) *EventBuilder {
+	b.e.Link = link
+	return b
+}
+
+//At sets the time that the event occurred. If not set, PostEvent uses the
+// time that the event is posted.
+func (b *EventBuilder) At(t time.Time) *EventBuilder {
+	b.e.OccurredAt = t
+	return b
+}
+
+//OK sets whether the event represents a working state
+func (b *EventBuilder) OK(ok bool) *EventBuilder {
+	b.e.OK = ok
+	return b
+}
+
+//Severity sets how bad the problem is, for a broken event
+func (b *EventBuilder) Severity(severity Severity) *EventBuilder {
+	b.e.Severity = severity
+	return b
+}
+
+//Metadata adds a metadata value to the event
+func (b *EventBuilder) Metadata(key, value string) *EventBuilder {
+	if b.e.Metadata == nil {
+		b.e.Metadata = map[string]string{}
+	}
+
+	b.e.Metadata[key] = value
+	return b
+}
+
+//Build returns the event, or an error if it fails Validate. An event without
+// an occurrence time is allowed, since PostEvent fills one in.
+func (b *EventBuilder) Build() (EventIn, error) {
+	check := b.e
+	if check.OccurredAt.IsZero() {
+		check.OccurredAt = time.Now()
+	}
+
+	err := check.Validate()
+	if err != nil {
+		return EventIn{}, err
+	}
+
+	ret := b.e
+	if ret.Metadata != nil {
+		ret.Metadata = make(map[string]string, len(b.e.Metadata))
+		for k, v := range b.e.Metadata {
+			ret.Metadata[k] = v
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,62 @@
+package shout
+
+import (
+	"context"
+	"time"
+)
+
+//CallOption changes how a single call is made, without affecting the client
+// or any other call made with it
+type CallOption func(*callConfig)
+
+type callConfig struct {
+	timeout time.Duration
+	noRetry bool
+}
+
+//WithCallTimeout bounds the whole call, including any retries and the waits
+// between them, to the given duration. It applies on top of the deadline of
+// the call's context and of Client.Timeout, which bounds each attempt, so
+// whichever is soonest wins.
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(cfg *callConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+//WithNoRetry makes the call give up after its first attempt, regardless of
+// Client.MaxRetries
+func WithNoRetry() CallOption {
+	return func(cfg *callConfig) {
+		cfg.noRetry = true
+	}
+}
+
+//forCall returns the client and context to make a call with, with the given
+// call options applied. The returned cancel function must be called once the
+// call is done. Without options, the client and context are returned as they
+// are.
+func (c *Client) forCall(ctx context.Context, opts []CallOption) (*Client, context.Context, context.CancelFunc) {
+	if len(opts) == 0 {
+		return c, ctx, func() {}
+	}
+
+	cfg := callConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	//A shallow copy shares everything that a clone would, and since it lasts
+	// only for this call, nothing needs to be copied deeper
+	call := *c
+	if cfg.noRetry {
+		call.MaxRetries = 0
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if cfg.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+	}
+
+	return &call, ctx, cancel
+}
@@ -0,0 +1,84 @@
+package shout
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+//Capture is the full content of one request sent to SHOUT! and the response to
+// it, given to Client.Capture for debugging. Credentials in the request
+// headers are redacted.
+type Capture struct {
+	//The method of the request
+	Method string
+	//The URL that the request was sent to
+	URL string
+	//Attempt is 1 for the first attempt at a request, and counts up with
+	// each retry
+	Attempt int
+	//RequestHeader holds the headers of the request, with Authorization and
+	// the like redacted
+	RequestHeader http.Header
+	//RequestBody is the body of the request as it was sent, which is gzipped
+	// if the client compressed it
+	RequestBody []byte
+	//StatusCode is the status code of the response, or zero if there was none
+	StatusCode int
+	//ResponseHeader holds the headers of the response, or nil if there was
+	// none
+	ResponseHeader http.Header
+	//ResponseBody is the body of the response, after it has been
+	// decompressed. It is cut off at Client.MaxResponseSize.
+	ResponseBody []byte
+	//Err is the error from sending the request or reading its response, if any
+	Err error
+}
+
+//captureResponse reads the whole body of the response so that it can be
+// given to the Capture callback, and replaces the body with one that reads
+// back the same bytes, followed by any error that reading it gave
+func (c *Client) captureResponse(req *http.Request, attempt int, body []byte, resp *http.Response) {
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	var r io.Reader = bytes.NewReader(b)
+	if err != nil {
+		r = io.MultiReader(r, errReader{err: err})
+	}
+	resp.Body = ioutil.NopCloser(r)
+
+	c.Capture(Capture{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		Attempt:        attempt,
+		RequestHeader:  redactHeader(req.Header),
+		RequestBody:    body,
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: resp.Header.Clone(),
+		ResponseBody:   b,
+		Err:            err,
+	})
+}
+
+//captureError gives the Capture callback a request that got no response
+func (c *Client) captureError(req *http.Request, attempt int, body []byte, err error) {
+	c.Capture(Capture{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		Attempt:       attempt,
+		RequestHeader: redactHeader(req.Header),
+		RequestBody:   body,
+		Err:           err,
+	})
+}
+
+//errReader is a reader that always fails with the given error
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
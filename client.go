@@ -2,24 +2,250 @@ package shout
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 //Client has functions that handle interactions with SHOUT!
+//
+//A Client is safe for concurrent use by multiple goroutines, provided that its
+// fields are not changed while requests are in flight. Any state that it
+// keeps between requests, such as for rate limiting, circuit breaking,
+// failover, and suppression of repeated events, is guarded internally. To
+// vary the configuration for some callers, use Clone or With instead of
+// changing a shared Client.
 type Client struct {
-	//Target is the URL that this client will hit with requests
-	Target   string
+	//Target is the URL that this client will hit with requests. A trailing
+	// slash is ignored
+	Target string
+	//MillisecondTimestamps, if true, causes times to be sent to and read from
+	// SHOUT! as milliseconds since the epoch, instead of seconds. Only set this
+	// if the SHOUT! server expects milliseconds.
+	MillisecondTimestamps bool
+	//MaxResponseSize is the largest response body, after decompression, that
+	// will be read. Reading past it gives ErrResponseTooLarge. If zero, 4MiB
+	// is used.
+	MaxResponseSize int64
+	//CompressionThreshold, if positive, causes request bodies larger than this
+	// many bytes to be sent gzipped, with a Content-Encoding of gzip. Smaller
+	// bodies are sent as they are. The SHOUT! server, or a proxy in front of
+	// it, must accept gzipped bodies.
+	CompressionThreshold int
+	//BasePath is prepended to the path of every request, for when SHOUT! is
+	// served under a prefix such as /api/v2. Leading and trailing slashes are
+	// optional.
+	BasePath string
+	//Username and Password, if either is non-empty, are sent with each request
+	// using HTTP basic auth
 	Username string
 	Password string
+	//Token, if non-empty, is sent as a bearer token in the Authorization header
+	// in place of Username and Password. An Authorization header given in
+	// Headers takes precedence over Token.
+	Token string
 	//HTTPClient is the net/http client that will be used to send requests.
-	// If left nil, http.DefaultClient will be used instead
+	// If left nil, the client uses its own, whose transport is a copy of
+	// http.DefaultTransport that keeps more idle connections open, shared by
+	// every Client that doesn't configure its transport. When HTTPClient is
+	// set, its own Timeout applies and the Timeout field of this struct is
+	// ignored, as are options that configure the transport, such as
+	// WithClientCert.
 	HTTPClient *http.Client
-	Trace      io.Writer
+	//Timeout is the time limit for each request, including reading the
+	// response body. It is only used when HTTPClient is nil. If zero, requests
+	// made without an HTTPClient have no time limit.
+	Timeout time.Duration
+	//RejectRedirects, if true, causes any 3xx response that the HTTP client
+	// did not follow itself to be treated as an error. Otherwise, only 4xx and
+	// 5xx responses are errors, and redirects are left to the redirect policy
+	// of the HTTP client.
+	RejectRedirects bool
+	//MaxMessageLength, if positive, is the most characters that the message
+	// of an event or announcement may have. Longer messages give an error
+	// before anything is sent, unless TruncateMessages is set. For an event,
+	// the error is a *ValidationError with a problem for the Message field.
+	MaxMessageLength int
+	//TruncateMessages, if true, causes messages longer than MaxMessageLength
+	// to be cut short to fit, ending in an ellipsis, instead of giving an error
+	TruncateMessages bool
+	//TopicPattern, if non-nil, must match the topic of every event and
+	// announcement, or it gives an error before anything is sent. For an
+	// event, the error is a *ValidationError with a problem for the Topic
+	// field. The pattern should be anchored with ^ and $ to be matched against
+	// the whole name.
+	TopicPattern *regexp.Regexp
+	//IsSuccess, if set, decides which response status codes are successes.
+	// Any other status gives an APIError. If nil, a status below 400 is a
+	// success, or below 300 if RejectRedirects is set.
+	IsSuccess func(statusCode int) bool
+	//DecodeJSON, if set, is used to decode the JSON bodies of responses from
+	// SHOUT! into v. If nil, a json.Decoder with default settings is used.
+	// DecodeJSONUseNumber may be given to keep large numbers exact.
+	DecodeJSON func(r io.Reader, v interface{}) error
+	//MaxRetries is the number of times a request will be retried after a
+	// connection error or a 429, 502, 503, or 504 response. It defaults to zero,
+	// meaning that requests are never retried. Retrying a request that SHOUT!
+	// did receive can cause duplicate notifications, so only enable this if
+	// that is acceptable.
+	MaxRetries int
+	//RetryBackoff is the delay before the first retry. Each retry after waits
+	// twice as long as the last, up to MaxRetryBackoff, with some random
+	// jitter. If zero, 250ms is used.
+	RetryBackoff time.Duration
+	//MaxRetryBackoff is the longest delay between retries. If zero, 10s is used
+	MaxRetryBackoff time.Duration
+	//MaxRetryAfter is the longest delay before a retry that will be accepted
+	// from the Retry-After header of a response. A longer Retry-After is
+	// shortened to this. If zero, 1m is used.
+	MaxRetryAfter time.Duration
+	//LenientLinks, if true, makes PostEvent send events whose Link is not an
+	// absolute URL instead of rejecting them, writing a warning to Trace
+	LenientLinks bool
+	//DryRun, if true, stops the client from sending anything to SHOUT!. Every
+	// method that would make a request instead returns ErrDryRun once its
+	// input has been checked. Use BuildRequest to see what would be sent.
+	DryRun bool
+	//WatchInterval is how often Watch polls for changes. If zero, 30s is used
+	WatchInterval time.Duration
+	//ReconnectBackoff is how long Watch and WatchStream wait before trying
+	// again after a failure. Each failure in a row after waits twice as long
+	// as the last, up to MaxReconnectBackoff, with some random variation. If
+	// zero, 1s is used.
+	ReconnectBackoff time.Duration
+	//MaxReconnectBackoff is the longest delay that Watch and WatchStream wait
+	// between tries. If zero, 1m is used.
+	MaxReconnectBackoff time.Duration
+	//UserAgent is sent as the User-Agent header of each request. If empty,
+	// DefaultUserAgent is sent instead
+	UserAgent string
+	//ContentType is sent as the Content-Type header of each request that has
+	// a body. If empty, application/json is sent. The body is JSON either
+	// way; this is for gateways that expect a vendor media type.
+	ContentType string
+	//Accept is sent as the Accept header of each request. If empty,
+	// application/json is sent.
+	Accept string
+	//APIVersion, if set, pins requests to a version of the SHOUT! API, by
+	// adding it as a version parameter to each media type of the Accept
+	// header, e.g. "application/json; version=2". The version that SHOUT!
+	// answers with is given by ServerAPIVersion.
+	APIVersion string
+	//BaseContext, if non-nil, is the context used for requests made by methods
+	// that don't take a context, such as PostEvent. Canceling it aborts any
+	// such requests in flight. Methods that take a context, such as
+	// PostEventCtx, use the context given to them instead.
+	BaseContext context.Context
+	//Logger, if non-nil, is told about every attempt at a request
+	Logger Logger
+	//Metrics, if non-nil, receives measurements of every request
+	Metrics Metrics
+	//Propagator, if non-nil, is given the context and headers of every request
+	// so that it can add tracing headers such as traceparent
+	Propagator Propagator
+	//RequestID, if non-nil, is called with the context of every request, and
+	// any non-empty string it returns is sent in the X-Request-Id header
+	RequestID func(ctx context.Context) string
+	//Headers are sent with every request. A header given here replaces any
+	// header of the same name that would otherwise be sent, such as
+	// Content-Type or Accept.
+	Headers http.Header
+	//Capture, if non-nil, is given the full headers and bodies of every
+	// request and its response, for debugging. Credentials in the request
+	// headers are redacted, but the bodies are given as they are. Response
+	// bodies are read in full before they are decoded, so this should only be
+	// set while debugging.
+	Capture func(Capture)
+	Trace   io.Writer
+
+	//clock returns the current time. If nil, time.Now is used
+	clock func() time.Time
+	//limiter, if non-nil, limits the rate that requests are sent at
+	limiter *rateLimiter
+	//suppressor, if non-nil, remembers recent posts so that repeats can be
+	// skipped
+	suppressor *postCache
+	//flights, if non-nil, coalesces identical posts that are in flight at
+	// the same time
+	flights *flightGroup
+	//topics, if non-nil, caches recently fetched topic states for GetTopic
+	topics *topicCache
+	//failover, if non-nil, holds the targets to try when Target fails
+	failover *targetSet
+	//transport, if non-nil, is used in place of the default transport when
+	// HTTPClient is nil. It is never modified once set, so that clones can
+	// share it
+	transport *http.Transport
+	//rateLimits, if non-nil, records the last rate limit status seen
+	rateLimits *rateLimitTracker
+	//apiVersions, if non-nil, records the last API version SHOUT! reported
+	apiVersions *apiVersionTracker
+	//breaker, if non-nil, stops requests being sent while SHOUT! is down
+	breaker *circuitBreaker
+	//middleware wrap the transport of the HTTP client, outermost first
+	middleware []func(http.RoundTripper) http.RoundTripper
+	//clients, if non-nil, holds the HTTP client built by httpClient, so that
+	// the middleware is applied once rather than for every request
+	clients *httpClientCache
+}
+
+//NewClient returns a Client that will send requests to the given target URL,
+// configured by the given options. An error is returned if the target is not
+// an absolute URL. Any trailing slash is stripped from the target.
+func NewClient(target string, opts ...Option) (*Client, error) {
+	err := validateTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		Target:      strings.TrimRight(target, "/"),
+		rateLimits:  &rateLimitTracker{},
+		apiVersions: &apiVersionTracker{},
+		clients:     &httpClientCache{},
+	}
+	for _, opt := range opts {
+		err = opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Token != "" && (c.Username != "" || c.Password != "") {
+		return nil, fmt.Errorf("Cannot use both basic auth and a bearer token")
+	}
+
+	return c, nil
+}
+
+//validateTarget returns an error if target is not an absolute URL
+func validateTarget(target string) error {
+	if target == "" {
+		return fmt.Errorf("No target given")
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("Could not parse target as URL: %s", err)
+	}
+
+	if !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("Target `%s' is not an absolute URL", target)
+	}
+
+	return nil
 }
 
 //EventIn is the input to PostEvent, and should contain information about the
@@ -31,32 +257,176 @@ type EventIn struct {
 	Message string
 	//A URL relevent to the event
 	Link string
-	//The time that the event occurred
+	//The time that the event occurred. If left zero, PostEvent uses the
+	// current time instead, which means that the zero time itself can't be sent
 	OccurredAt time.Time
 	//True if the event represents a "working" state. False if "broken"
 	OK bool
+	//If true, the event is only recorded on the topic's timeline and does not
+	// assert that the topic is working or broken, so OK is not sent
+	Informational bool
+	//How bad the problem is, for a broken event. Must be left as SeverityNone
+	// for a working or informational event
+	Severity Severity
 	//Optional values to pass through to the user that can be used in the rules file
 	Metadata map[string]string
+	//An optional key sent in the Idempotency-Key header, which SHOUT! can use
+	// to recognize repeats of the same event. If left empty and retries are
+	// enabled on the Client, a random key is generated for each call to
+	// PostEvent. Retries of a call always send the same key.
+	IdempotencyKey string
+}
+
+//newIdempotencyKey returns a random key for the Idempotency-Key header
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
-func (c *Client) doRequest(method, path string, body []byte) (*http.Response, error) {
-	client := c.HTTPClient
-	if client == nil {
-		client = http.DefaultClient
+//Validate returns an error if the event is obviously malformed: if it has no
+// topic, no occurrence time, a link that is not an absolute URL, or a severity
+// while OK. PostEvent fills in a missing occurrence time before validating.
+// The error is a *ValidationError listing every problem found.
+func (e EventIn) Validate() error {
+	v := &ValidationError{}
+	if e.Topic == "" {
+		v.add("Topic", "Event has no topic")
 	}
 
-	req, err := http.NewRequest(method,
-		fmt.Sprintf("%s%s", c.Target, path),
-		bytes.NewReader(body),
-	)
+	if e.OccurredAt.IsZero() {
+		v.add("OccurredAt", "Event has no occurrence time")
+	}
 
+	if e.Link != "" {
+		err := validateLink(e.Link)
+		if err != nil {
+			v.add("Link", err.Error())
+		}
+	}
+
+	switch e.Severity {
+	case SeverityNone:
+	case SeverityWarning, SeverityCritical:
+		if e.OK {
+			v.add("Severity", "Working event cannot have a severity")
+		}
+
+		if e.Informational {
+			v.add("Severity", "Informational event cannot have a severity")
+		}
+	default:
+		v.add("Severity", fmt.Sprintf("Event has unknown severity %d", int(e.Severity)))
+	}
+
+	if len(v.Problems) > 0 {
+		return v
+	}
+
+	return nil
+}
+
+//validateLink returns an error if link is not an absolute URL that could be
+// followed from a notification
+func validateLink(link string) error {
+	if strings.ContainsAny(link, " \t\r\n") {
+		return fmt.Errorf("Event link `%s' contains whitespace", link)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("Event link is not a valid URL: %s", err)
+	}
+
+	if !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("Event link `%s' is not an absolute URL", link)
+	}
+
+	return nil
+}
+
+//doRequest sends a request to SHOUT!, retrying it if configured to. header
+// holds any headers specific to this request, and may be nil.
+func (c *Client) doRequest(ctx context.Context, method, path string, header http.Header, body []byte) (*http.Response, error) {
+	//Don't bother sending anything if the caller has already given up
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	header, body, err := c.encodeBody(header, body)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.SetBasicAuth(c.Username, c.Password)
+	if c.DryRun {
+		return nil, ErrDryRun
+	}
+
+	var resp *http.Response
+	attempts := 0
+	if c.Metrics != nil {
+		start := time.Now()
+		defer func() {
+			c.Metrics.ObserveCall(method, routeOf(path), statusClass(resp, err), attempts, time.Since(start))
+		}()
+	}
+
+	for {
+		if c.breaker != nil && !c.breaker.allow(c.now()) {
+			err = ErrCircuitOpen
+			return nil, err
+		}
+
+		attempts++
+		resp, err = c.doFailover(ctx, attempts, method, path, header, body)
+		if c.breaker != nil {
+			if err != nil && ctx.Err() != nil {
+				//The caller gave up, which says nothing about SHOUT!
+				c.breaker.abandon()
+			} else {
+				c.breaker.record(err != nil && shouldFailover(err), c.now())
+			}
+		}
+
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+			return nil, err
+		}
+
+		if err == nil || attempts > c.MaxRetries || !isRetryable(err) {
+			break
+		}
+
+		err = c.waitToRetry(ctx, attempts, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err != nil && attempts > 1 {
+		err = fmt.Errorf("Giving up after %d attempts: %w", attempts, err)
+		return nil, err
+	}
+
+	return resp, err
+}
+
+//doAttempt makes a single attempt at sending a request to the given target.
+// attempt counts from 1
+func (c *Client) doAttempt(ctx context.Context, attempt int, target, method, path string, header http.Header, body []byte) (*http.Response, error) {
+	if c.limiter != nil {
+		err := c.limiter.Wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := c.httpClient()
+
+	req, err := c.newRequest(ctx, target, method, path, header, body)
+	if err != nil {
+		return nil, err
+	}
 
 	if c.Trace != nil {
 		b, _ := httputil.DumpRequestOut(req, true)
@@ -64,30 +434,321 @@ func (c *Client) doRequest(method, path string, body []byte) (*http.Response, er
 		c.Trace.Write([]byte("\n"))
 	}
 
+	start := time.Now()
 	resp, err := client.Do(req)
+	duration := time.Since(start)
+	if c.Logger != nil {
+		c.logRequest(ctx, req, path, attempt, resp, err, duration)
+	}
+
+	if c.Metrics != nil {
+		c.Metrics.ObserveAttempt(method, routeOf(path), statusClass(resp, err), duration)
+	}
+
+	if err != nil {
+		if c.Capture != nil {
+			c.captureError(req, attempt, body, err)
+		}
+
+		//If the context was canceled or timed out, report that instead of the
+		// transport error that wraps it
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &transportError{err: err}
+	}
+
+	if c.rateLimits != nil {
+		c.rateLimits.update(resp.Header, c.now())
+	}
+
+	if c.apiVersions != nil {
+		c.apiVersions.update(resp.Header)
+	}
+
+	err = decompressResponse(resp)
+	if err != nil {
+		c.traceResponse(resp, false)
+		return nil, fmt.Errorf("Could not decompress response: %s", err)
+	}
+
+	maxSize := c.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxResponseSize
+	}
+	resp.Body = &limitedBody{body: resp.Body, remaining: maxSize}
+	c.traceResponse(resp, true)
+
+	if c.Capture != nil {
+		c.captureResponse(req, attempt, body, resp)
+	}
+
+	if !c.isSuccess(resp.StatusCode) {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       b,
+			Message:    parseErrorMessage(b),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), c.now()),
+		}
+	}
+
+	return resp, nil
+}
+
+//newRequest builds a request to the given target, with all of the headers
+// that the client is configured to send
+func (c *Client) newRequest(ctx context.Context, target, method, path string, header http.Header, body []byte) (*http.Request, error) {
+	//A request without a body is sent without one, rather than with an empty
+	// one, so that it has no Content-Length or Content-Type
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method,
+		fmt.Sprintf("%s%s", strings.TrimRight(target, "/"), c.fullPath(path)),
+		reader,
+	)
+
 	if err != nil {
 		return nil, err
 	}
 
+	if len(body) > 0 {
+		contentType := c.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	accept := c.Accept
+	if accept == "" {
+		accept = "application/json"
+	}
+	if c.APIVersion != "" {
+		accept = withVersion(accept, c.APIVersion)
+	}
+	req.Header.Set("Accept", accept)
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	for k, v := range c.Headers {
+		req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if c.RequestID != nil {
+		if id := c.RequestID(ctx); id != "" {
+			req.Header.Set("X-Request-Id", id)
+		}
+	}
+
+	if c.Propagator != nil {
+		c.Propagator.Inject(ctx, req.Header)
+	}
+
+	return req, nil
+}
+
+//encodeBody compresses the body if it is large enough for the client to be
+// configured to, returning the headers and body to send
+func (c *Client) encodeBody(header http.Header, body []byte) (http.Header, []byte, error) {
+	if c.CompressionThreshold <= 0 || len(body) <= c.CompressionThreshold {
+		return header, body, nil
+	}
+
+	compressed, err := gzipBytes(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not compress request body: %s", err)
+	}
+
+	header = cloneHeader(header)
+	header.Set("Content-Encoding", "gzip")
+	return header, compressed, nil
+}
+
+//fullPath returns the given API path with BasePath prepended
+func (c *Client) fullPath(path string) string {
+	base := normalizeBasePath(c.BasePath)
+	return base + "/" + strings.TrimLeft(path, "/")
+}
+
+//normalizeBasePath gives the path a leading slash and no trailing slash, and
+// turns a path of only slashes into an empty string
+func normalizeBasePath(path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return ""
+	}
+
+	return "/" + path
+}
+
+//context returns the context used by methods that aren't given one
+func (c *Client) context() context.Context {
+	if c.BaseContext != nil {
+		return c.BaseContext
+	}
+
+	return context.Background()
+}
+
+//traceResponse writes the response to Trace, if it is set. The body is only
+// dumped if asked for, and only up to MaxResponseSize; a body that is too large
+// is left to fail with ErrResponseTooLarge when it is read, and only the
+// headers are dumped.
+func (c *Client) traceResponse(resp *http.Response, body bool) {
+	if c.Trace == nil {
+		return
+	}
+
+	b, err := httputil.DumpResponse(resp, body)
+	if err != nil {
+		b, _ = httputil.DumpResponse(resp, false)
+	}
+	c.Trace.Write(b)
+	c.Trace.Write([]byte("\n"))
+}
+
+//warn writes a warning to Trace, if it is set
+func (c *Client) warn(format string, args ...interface{}) {
 	if c.Trace != nil {
-		b, _ := httputil.DumpResponse(resp, true)
-		c.Trace.Write(b)
-		c.Trace.Write([]byte("\n"))
+		fmt.Fprintf(c.Trace, "WARNING: "+format+"\n", args...)
 	}
+}
 
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("SHOUT! returned non-2xx status code: %s", resp.Status)
+func (c *Client) now() time.Time {
+	if c.clock != nil {
+		return c.clock()
 	}
 
-	return resp, nil
+	return time.Now()
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.clients == nil {
+		return c.buildHTTPClient()
+	}
+
+	return c.clients.get(c)
+}
+
+//buildHTTPClient returns the HTTP client to send requests with, with the
+// middleware applied to its transport
+func (c *Client) buildHTTPClient() *http.Client {
+	client := c.baseHTTPClient()
+	if len(c.middleware) == 0 {
+		return client
+	}
+
+	//Work on a copy so that a client given to us is never modified
+	wrapped := *client
+	transport := wrapped.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
+	}
+
+	wrapped.Transport = transport
+	return &wrapped
+}
+
+func (c *Client) baseHTTPClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	transport := c.transport
+	if transport == nil {
+		transport = defaultTransport()
+	}
+
+	return &http.Client{Timeout: c.Timeout, Transport: transport}
+}
+
+//checkTopic returns an error if the topic does not match TopicPattern
+func (c *Client) checkTopic(topic string) error {
+	if c.TopicPattern != nil && !c.TopicPattern.MatchString(topic) {
+		return fmt.Errorf("Topic `%s' does not match the pattern `%s'", topic, c.TopicPattern)
+	}
+
+	return nil
+}
+
+//limitMessage applies MaxMessageLength to the message, truncating it or
+// returning an error as the client is configured to
+func (c *Client) limitMessage(msg string) (string, error) {
+	if c.MaxMessageLength <= 0 {
+		return msg, nil
+	}
+
+	length := utf8.RuneCountInString(msg)
+	if length <= c.MaxMessageLength {
+		return msg, nil
+	}
+
+	if !c.TruncateMessages {
+		return "", fmt.Errorf("Message is %d characters long, which is more than the limit of %d", length, c.MaxMessageLength)
+	}
+
+	const ellipsis = "…"
+	keep := c.MaxMessageLength - utf8.RuneCountInString(ellipsis)
+	if keep <= 0 {
+		return string([]rune(msg)[:c.MaxMessageLength]), nil
+	}
+
+	return string([]rune(msg)[:keep]) + ellipsis, nil
+}
+
+//isSuccess returns true if the status code of a response means that the
+// request succeeded
+func (c *Client) isSuccess(statusCode int) bool {
+	if c.IsSuccess != nil {
+		return c.IsSuccess(statusCode)
+	}
+
+	if c.RejectRedirects {
+		return statusCode < 300
+	}
+
+	return statusCode < 400
+}
+
+//drainAndClose reads the rest of the body and closes it, so that the
+// underlying connection can be reused
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, body)
+	body.Close()
 }
 
 type eventRaw struct {
-	OccurredAt int64  `json:"occurred-at"`
-	ReportedAt int64  `json:"reported-at"`
-	OK         bool   `json:"ok"`
-	Message    string `json:"message"`
-	Link       string `json:"link"`
+	OccurredAt int64             `json:"occurred-at"`
+	ReportedAt int64             `json:"reported-at"`
+	OK         *bool             `json:"ok"`
+	Message    string            `json:"message"`
+	Link       string            `json:"link"`
+	Severity   string            `json:"severity"`
+	Metadata   map[string]string `json:"metadata"`
 }
 
 type stateRaw struct {
@@ -98,30 +759,585 @@ type stateRaw struct {
 	Last     eventRaw `json:"last"`
 }
 
+//TopicState represents the state of a topic as reported by SHOUT!
+type TopicState int
+
+const (
+	//TopicWorking means that the topic was working, and is still working
+	TopicWorking TopicState = iota
+	//TopicFixed means that the topic was broken, and is now working
+	TopicFixed
+	//TopicBroken means that the topic is not working
+	TopicBroken
+	//TopicSilenced means that notifications for the topic have been silenced
+	// with SilenceTopic, so whether it is working is not reported
+	TopicSilenced
+	//TopicAcknowledged means that the topic is broken, but someone has
+	// acknowledged it with AcknowledgeTopic
+	TopicAcknowledged
+	//TopicUnreported means that the state of the topic is not known. SHOUT!
+	// never sends it; PostEvent and Heartbeat give it when SHOUT! accepts an
+	// informational event without reporting the state back, since such an
+	// event says nothing about whether the topic is working.
+	TopicUnreported
+)
+
+//String returns the name SHOUT! uses for the state, "unreported" for
+// TopicUnreported, or "unknown(<n>)" if the state is not one that this package
+// knows about
+func (t TopicState) String() string {
+	switch t {
+	case TopicWorking:
+		return "working"
+	case TopicFixed:
+		return "fixed"
+	case TopicBroken:
+		return "broken"
+	case TopicSilenced:
+		return "silenced"
+	case TopicAcknowledged:
+		return "acknowledged"
+	case TopicUnreported:
+		return "unreported"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+//OK returns true if the state is one where the topic is working, which is
+// either TopicWorking or TopicFixed. A silenced, acknowledged, or unreported
+// topic is not considered to be working.
+func (t TopicState) OK() bool {
+	return t == TopicWorking || t == TopicFixed
+}
+
+//MarshalJSON encodes the state as its SHOUT! name, e.g. "broken", or as
+// "unreported" for TopicUnreported
+func (t TopicState) MarshalJSON() ([]byte, error) {
+	switch t {
+	case TopicWorking, TopicFixed, TopicBroken, TopicSilenced, TopicAcknowledged, TopicUnreported:
+		return json.Marshal(t.String())
+	}
+
+	return nil, fmt.Errorf("Cannot marshal unknown topic state %d", int(t))
+}
+
+//UnmarshalJSON decodes a state from its SHOUT! name, or from "unreported" as
+// given by MarshalJSON. An error is returned if the name is not a known state.
+func (t *TopicState) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	if s == "unreported" {
+		*t = TopicUnreported
+		return nil
+	}
+
+	state, err := parseState(s)
+	if err != nil {
+		return err
+	}
+
+	*t = state
+	return nil
+}
+
+func parseState(s string) (TopicState, error) {
+	switch s {
+	case "working":
+		return TopicWorking, nil
+	case "fixed":
+		return TopicFixed, nil
+	case "broken":
+		return TopicBroken, nil
+	case "silenced":
+		return TopicSilenced, nil
+	case "acknowledged":
+		return TopicAcknowledged, nil
+	}
+
+	return 0, fmt.Errorf("Unknown topic state `%s'", s)
+}
+
+//EventOut is an event as reported back by SHOUT!. Its times are in UTC.
+type EventOut struct {
+	//The time that the event occurred
+	OccurredAt time.Time
+	//The time that SHOUT! received the event
+	ReportedAt time.Time
+	//True if the event represented a "working" state. False if "broken"
+	OK bool
+	//True if the event did not say whether the topic was working or broken
+	Informational bool
+	//A message about the event
+	Message string
+	//A URL relevant to the event
+	Link string
+	//How bad the problem was, if SHOUT! was told
+	Severity Severity
+	//The metadata given with the event, if any
+	Metadata map[string]string
+}
+
+//String summarizes the event for logging, in the form:
+//
+//	ok=false message="disk full" occurred=2006-01-02T15:04:05Z reported=2006-01-02T15:04:06Z
+//
+//Times are given in UTC, and a time that is unset is given as "none".
+func (e EventOut) String() string {
+	return fmt.Sprintf("ok=%t message=%q occurred=%s reported=%s",
+		e.OK, e.Message, formatTime(e.OccurredAt), formatTime(e.ReportedAt))
+}
+
+//formatTime formats t in UTC as RFC3339, or as "none" if it is the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+//Age returns how long before now the event occurred
+func (e EventOut) Age(now time.Time) time.Duration {
+	return now.Sub(e.OccurredAt)
+}
+
+func parseEvent(event eventRaw, millis bool) EventOut {
+	ret := EventOut{
+		OccurredAt:    fromEpoch(event.OccurredAt, millis),
+		ReportedAt:    fromEpoch(event.ReportedAt, millis),
+		Informational: event.OK == nil,
+		Message:       event.Message,
+		Link:          event.Link,
+		Severity:      parseSeverity(event.Severity),
+		Metadata:      event.Metadata,
+	}
+
+	if event.OK != nil {
+		ret.OK = *event.OK
+	}
+
+	return ret
+}
+
+//StateOut is the state of a topic as reported back by SHOUT!
+type StateOut struct {
+	//The name of the topic
+	Name string
+	//The current state of the topic
+	State TopicState
+	//The event before the most recent event
+	Previous EventOut
+	//The first event of the current state
+	First EventOut
+	//The most recent event
+	Last EventOut
+}
+
+//String summarizes the state for logging, in the form:
+//
+//	name="my-topic" state=broken last=2006-01-02T15:04:05Z
+//
+//where last is the time that the most recent event occurred, in UTC, or
+// "none" if there has been no event.
+func (s StateOut) String() string {
+	return fmt.Sprintf("name=%q state=%s last=%s", s.Name, s.State, formatTime(s.Last.OccurredAt))
+}
+
+//IsWorking returns true if the topic has been working since its last event
+func (s StateOut) IsWorking() bool {
+	return s.State == TopicWorking
+}
+
+//IsFixed returns true if the topic was broken, and its last event fixed it
+func (s StateOut) IsFixed() bool {
+	return s.State == TopicFixed
+}
+
+//IsBroken returns true if the topic is broken. A broken topic that has been
+// acknowledged is reported as TopicAcknowledged instead; use IsAcknowledged to
+// check for that.
+func (s StateOut) IsBroken() bool {
+	return s.State == TopicBroken
+}
+
+//IsAcknowledged returns true if the topic is broken, and has been acknowledged
+func (s StateOut) IsAcknowledged() bool {
+	return s.State == TopicAcknowledged
+}
+
+//IsSilenced returns true if notifications for the topic are silenced
+func (s StateOut) IsSilenced() bool {
+	return s.State == TopicSilenced
+}
+
+//Duration returns how long the topic had been in its current state as of its
+// most recent event, which is the time between the first and last events of
+// the state. To find how long it has been in the state as of some time, use
+// s.First.Age(now).
+func (s StateOut) Duration() time.Duration {
+	return s.Last.OccurredAt.Sub(s.First.OccurredAt)
+}
+
+func parseStateRaw(raw stateRaw, millis bool) (*StateOut, error) {
+	state, err := parseState(raw.State)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StateOut{
+		Name:     raw.Name,
+		State:    state,
+		Previous: parseEvent(raw.Previous, millis),
+		First:    parseEvent(raw.First, millis),
+		Last:     parseEvent(raw.Last, millis),
+	}, nil
+}
+
+//decodeState reads a single topic state from the body of the response, and
+// closes the body
+func (c *Client) decodeState(resp *http.Response) (*StateOut, error) {
+	raw := stateRaw{}
+	err := c.decodeJSON(resp, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStateRaw(raw, c.MillisecondTimestamps)
+}
+
+//decodeJSON decodes the body of the response into v with the client's
+// DecodeJSON, and closes the body. The whole body is read first, so that it can
+// be put on the InvalidResponseError if it can't be decoded, and so that the
+// connection can be reused. The body has already been decompressed and limited
+// in size by doRequest.
+func (c *Client) decodeJSON(resp *http.Response, v interface{}) error {
+	defer drainAndClose(resp.Body)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Could not read response body: %w", err)
+	}
+
+	decode := c.DecodeJSON
+	if decode == nil {
+		decode = decodeJSON
+	}
+
+	err = decode(bytes.NewReader(b), v)
+	if err != nil {
+		if len(b) > maxErrorBodySize {
+			b = b[:maxErrorBodySize]
+		}
+
+		return &InvalidResponseError{
+			StatusCode:  resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        b,
+			Err:         err,
+		}
+	}
+
+	return nil
+}
+
+//decodeJSON is the default Client.DecodeJSON
+func decodeJSON(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+//DecodeJSONUseNumber is a Client.DecodeJSON that decodes numbers into
+// interface{} values as json.Number instead of float64, so that large
+// integers are kept exactly. Numbers decoded into integer fields are exact
+// either way.
+func DecodeJSONUseNumber(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
 //PostEvent sends the given event to SHOUT! to update the state of the topic.
 // The event will send a message to notification backends configured by the
-// rules of the SHOUT! backend if the state has changed
-func (c *Client) PostEvent(e EventIn) error {
+// rules of the SHOUT! backend if the state has changed. The resulting state of
+// the topic is returned. If SHOUT! responds with 204 No Content or an empty
+// body, the returned state has only the topic name and the state implied by
+// the event, with no events; for an informational event, which implies no
+// state, that is TopicUnreported. Call options, such as WithCallTimeout and
+// WithNoRetry, apply to this call only.
+func (c *Client) PostEvent(e EventIn, opts ...CallOption) (*StateOut, error) {
+	return c.PostEventCtx(c.context(), e, opts...)
+}
+
+//PostEventCtx is PostEvent, but the request is bound to the given context. If
+// the context is canceled or its deadline passes while the request is in
+// flight, the request is aborted and the context's error is returned. The event
+// is checked with Validate before anything is sent.
+func (c *Client) PostEventCtx(ctx context.Context, e EventIn, opts ...CallOption) (*StateOut, error) {
+	call, ctx, cancel := c.forCall(ctx, opts)
+	defer cancel()
+
+	state, _, err := call.postEvent(ctx, e)
+	return state, err
+}
+
+//PostEventChange is PostEvent, but it also reports whether the event changed
+// the state of the topic. The event is counted as a change if the resulting
+// state is TopicFixed, or if it is TopicBroken and the event before it was a
+// working one, or there was no event before it. Silenced and acknowledged
+// topics, informational events, and events skipped by WithSuppressUnchanged
+// never count as changes.
+func (c *Client) PostEventChange(e EventIn) (*StateOut, bool, error) {
+	return c.PostEventChangeCtx(c.context(), e)
+}
+
+//PostEventChangeCtx is PostEventChange, but the request is bound to the given
+// context
+func (c *Client) PostEventChangeCtx(ctx context.Context, e EventIn) (*StateOut, bool, error) {
+	state, suppressed, err := c.postEvent(ctx, e)
+	if err != nil {
+		return nil, false, err
+	}
+
+	changed := !suppressed && !e.Informational && transitioned(*state)
+	return state, changed, nil
+}
+
+//transitioned returns true if the most recent event of the state is the one
+// that put the topic into it
+func transitioned(s StateOut) bool {
+	if s.Last.OccurredAt.IsZero() {
+		//SHOUT! didn't report the state back
+		return false
+	}
+
+	switch s.State {
+	case TopicFixed:
+		return true
+	case TopicBroken:
+		return s.Previous.OccurredAt.IsZero() || s.Previous.OK
+	}
+
+	return false
+}
+
+//unreportedState is the state returned for an event when SHOUT! accepts it
+// without reporting the state back, which it may do when the event did not
+// change the state. It has the name of the topic, the state that the event
+// implies, and no events. An informational event implies no state, so it gives
+// TopicUnreported.
+func unreportedState(e EventIn) *StateOut {
+	state := &StateOut{Name: e.Topic}
+	switch {
+	case e.Informational:
+		state.State = TopicUnreported
+	case !e.OK:
+		state.State = TopicBroken
+	}
+
+	return state
+}
+
+//postEvent is PostEventCtx, but also returns true if the event was skipped by
+// the suppressor
+func (c *Client) postEvent(ctx context.Context, e EventIn) (*StateOut, bool, error) {
+	if c.suppressor != nil && !e.Informational {
+		if state, found := c.suppressor.get(e.Topic, e.OK, e.Severity, c.now()); found {
+			return state, true, nil
+		}
+	}
+
+	var (
+		state *StateOut
+		err   error
+	)
+	if c.flights != nil {
+		state, err = c.flights.do(ctx, coalesceKey(e), func() (*StateOut, error) {
+			state, _, err := c.PostEventRawCtx(ctx, e)
+			return state, err
+		})
+	} else {
+		state, _, err = c.PostEventRawCtx(ctx, e)
+	}
+
+	if err == nil && c.suppressor != nil && !e.Informational {
+		c.suppressor.put(e.Topic, e.OK, e.Severity, *state, c.now())
+	}
+
+	return state, false, err
+}
+
+//Heartbeat tells SHOUT! that whatever reports on the topic is still alive,
+// without saying whether the topic is working or broken. SHOUT! has no
+// dedicated heartbeat endpoint, so this posts an informational event with the
+// message "heartbeat". Because an informational event asserts no state, it
+// never causes a transition to fixed or broken, and so sends no notification
+// for it, but it does update the last event of the topic and is recorded on
+// its timeline. The resulting state of the topic is returned.
+func (c *Client) Heartbeat(topic string) (*StateOut, error) {
+	return c.HeartbeatCtx(c.context(), topic)
+}
+
+//HeartbeatCtx is Heartbeat, but the request is bound to the given context
+func (c *Client) HeartbeatCtx(ctx context.Context, topic string) (*StateOut, error) {
+	return c.PostEventCtx(ctx, EventIn{
+		Topic:         topic,
+		Message:       "heartbeat",
+		Informational: true,
+	})
+}
+
+//PostEventRaw is PostEvent, but the HTTP response from SHOUT! is also
+// returned so that its status code and headers can be inspected. Unlike
+// PostEvent, it always sends the event, even if WithSuppressUnchanged or
+// WithCoalescing is in use. The body of the response has already been read and
+// closed, and is replaced with http.NoBody; callers need not close it. The
+// response is nil if the error is non-nil.
+func (c *Client) PostEventRaw(e EventIn) (*StateOut, *http.Response, error) {
+	return c.PostEventRawCtx(c.context(), e)
+}
+
+//PostEventRawCtx is PostEventRaw, but the request is bound to the given
+// context
+func (c *Client) PostEventRawCtx(ctx context.Context, e EventIn) (*StateOut, *http.Response, error) {
+	header, body, err := c.eventRequest(e)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/events", header, body)
+	if err != nil {
+		c.InvalidateTopic(e.Topic)
+		return nil, nil, err
+	}
+
+	var state *StateOut
+	if resp.StatusCode == http.StatusNoContent {
+		drainAndClose(resp.Body)
+		state = unreportedState(e)
+	} else {
+		state, err = c.decodeState(resp)
+		var invalid *InvalidResponseError
+		if errors.As(err, &invalid) && len(bytes.TrimSpace(invalid.Body)) == 0 {
+			state, err = unreportedState(e), nil
+		}
+		if err != nil {
+			c.InvalidateTopic(e.Topic)
+			return nil, nil, err
+		}
+	}
+
+	if c.topics != nil {
+		c.topics.invalidate(e.Topic)
+		if !state.Last.OccurredAt.IsZero() {
+			c.topics.put(e.Topic, *state, "", c.now())
+		}
+	}
+
+	resp.Body = http.NoBody
+	return state, resp, nil
+}
+
+//BuildRequest returns the request that PostEvent would send for the event,
+// without sending it, so that the URL, headers, and body can be inspected.
+// Only the first target is used, and any retry would be the same request.
+func (c *Client) BuildRequest(e EventIn) (*http.Request, error) {
+	header, body, err := c.eventRequest(e)
+	if err != nil {
+		return nil, err
+	}
+
+	header, body, err = c.encodeBody(header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newRequest(c.context(), c.Target, "POST", "/events", header, body)
+}
+
+//eventRequest validates the event and returns the headers and body of the
+// request that posts it
+func (c *Client) eventRequest(e EventIn) (http.Header, []byte, error) {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = c.now()
+	}
+
+	message, messageErr := c.limitMessage(e.Message)
+	if messageErr == nil {
+		e.Message = message
+	}
+
+	check := e
+	if c.LenientLinks && e.Link != "" {
+		if err := validateLink(e.Link); err != nil {
+			c.warn("Sending event with bad link anyway: %s", err)
+			check.Link = ""
+		}
+	}
+
+	//Collect the problems that the client's own settings find along with
+	// those that Validate finds, so that all are reported at once
+	v := &ValidationError{}
+	if err := check.Validate(); err != nil {
+		v = err.(*ValidationError)
+	}
+
+	if e.Topic != "" {
+		if err := c.checkTopic(e.Topic); err != nil {
+			v.add("Topic", err.Error())
+		}
+	}
+
+	if messageErr != nil {
+		v.add("Message", messageErr.Error())
+	}
+
+	if len(v.Problems) > 0 {
+		return nil, nil, v
+	}
+
 	jsonStruct := struct {
 		Topic      string            `json:"topic"`
 		Message    string            `json:"message"`
 		Link       string            `json:"link"`
 		OccurredAt int64             `json:"occurred-at"`
-		OK         bool              `json:"ok"`
+		OK         *bool             `json:"ok,omitempty"`
+		Severity   string            `json:"severity,omitempty"`
 		Metadata   map[string]string `json:"metadata,omitempty"`
 	}{
 		Topic:      e.Topic,
-		OK:         e.OK,
+		Severity:   e.Severity.String(),
 		Message:    e.Message,
 		Link:       e.Link,
-		OccurredAt: e.OccurredAt.Unix(),
+		OccurredAt: toEpoch(e.OccurredAt, c.MillisecondTimestamps),
 		Metadata:   e.Metadata,
 	}
 
-	jBytes, _ := json.Marshal(&jsonStruct)
+	if !e.Informational {
+		jsonStruct.OK = &e.OK
+	}
+
+	jBytes, err := json.Marshal(&jsonStruct)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not marshal event as JSON: %s", err)
+	}
+
+	//The key is decided once here so that every retry of the request carries
+	// the same one
+	header := http.Header{}
+	idempotencyKey := e.IdempotencyKey
+	if idempotencyKey == "" && c.MaxRetries > 0 {
+		idempotencyKey = newIdempotencyKey()
+	}
 
-	_, err := c.doRequest("POST", "/events", jBytes)
-	return err
+	if idempotencyKey != "" {
+		header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	return header, jBytes, nil
 }
 
 //AnnouncementIn is the input to PostAnnouncement, containing information about
@@ -133,13 +1349,144 @@ type AnnouncementIn struct {
 	Message string `json:"message"`
 	//A URL relevant to the announcement
 	Link string `json:"link"`
+	//The time that the announcement was made. If left zero, SHOUT! uses the
+	// time that it receives the announcement
+	OccurredAt time.Time `json:"-"`
+}
+
+//MarshalJSON encodes the announcement in the form SHOUT! expects, including
+// the occurrence time only if one was set. The occurrence time is given in
+// seconds.
+func (a AnnouncementIn) MarshalJSON() ([]byte, error) {
+	return a.marshal(false)
+}
+
+func (a AnnouncementIn) marshal(millis bool) ([]byte, error) {
+	jsonStruct := struct {
+		Topic      string `json:"topic"`
+		Message    string `json:"message"`
+		Link       string `json:"link"`
+		OccurredAt int64  `json:"occurred-at,omitempty"`
+	}{
+		Topic:   a.Topic,
+		Message: a.Message,
+		Link:    a.Link,
+	}
+
+	if !a.OccurredAt.IsZero() {
+		jsonStruct.OccurredAt = toEpoch(a.OccurredAt, millis)
+	}
+
+	return json.Marshal(&jsonStruct)
 }
 
 //PostAnnouncement sends a message that goes to notification backends configured
 // by the rules of the SHOUT! backend. This has no concept of a "working" or
 // "broken" state, and so the message is always sent.
 func (c *Client) PostAnnouncement(announcement AnnouncementIn) error {
-	jBytes, _ := json.Marshal(&announcement)
-	_, err := c.doRequest("POST", "/events", jBytes)
-	return err
+	return c.PostAnnouncementCtx(c.context(), announcement)
+}
+
+//PostAnnouncementCtx is PostAnnouncement, but the request is bound to the
+// given context. If the context is already done, no request is sent and the
+// context's error is returned.
+func (c *Client) PostAnnouncementCtx(ctx context.Context, announcement AnnouncementIn) error {
+	resp, err := c.postAnnouncement(ctx, announcement)
+	if err != nil {
+		return err
+	}
+
+	drainAndClose(resp.Body)
+	return nil
+}
+
+//AnnouncementOut is what SHOUT! reports back about an announcement that it
+// has accepted. Fields that SHOUT! does not send are left zero.
+type AnnouncementOut struct {
+	//The identifier SHOUT! assigned to the announcement, which notification
+	// backends may also be given
+	ID string
+	//The time that SHOUT! received the announcement
+	ReportedAt time.Time
+	//The names of the notification backends that the announcement was sent to
+	DeliveredTo []string
+}
+
+type announcementRaw struct {
+	ID          string   `json:"id"`
+	ReportedAt  int64    `json:"reported-at"`
+	DeliveredTo []string `json:"delivered-to"`
+}
+
+//PostAnnouncementResult is PostAnnouncement, but what SHOUT! reports back
+// about the announcement is returned, so that it can be correlated with the
+// notifications that it caused. If SHOUT! sends back an empty body, an empty
+// AnnouncementOut is returned.
+func (c *Client) PostAnnouncementResult(announcement AnnouncementIn) (*AnnouncementOut, error) {
+	return c.PostAnnouncementResultCtx(c.context(), announcement)
+}
+
+//PostAnnouncementResultCtx is PostAnnouncementResult, but the request is bound
+// to the given context
+func (c *Client) PostAnnouncementResultCtx(ctx context.Context, announcement AnnouncementIn) (*AnnouncementOut, error) {
+	resp, err := c.postAnnouncement(ctx, announcement)
+	if err != nil {
+		return nil, err
+	}
+
+	defer drainAndClose(resp.Body)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read response body: %s", err)
+	}
+
+	if len(bytes.TrimSpace(b)) == 0 {
+		return &AnnouncementOut{}, nil
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	raw := announcementRaw{}
+	err = c.decodeJSON(resp, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AnnouncementOut{
+		ID:          raw.ID,
+		ReportedAt:  fromEpoch(raw.ReportedAt, c.MillisecondTimestamps),
+		DeliveredTo: raw.DeliveredTo,
+	}, nil
+}
+
+func (c *Client) postAnnouncement(ctx context.Context, announcement AnnouncementIn) (*http.Response, error) {
+	err := c.checkTopic(announcement.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	announcement.Message, err = c.limitMessage(announcement.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	jBytes, err := announcement.marshal(c.MillisecondTimestamps)
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal announcement as JSON: %s", err)
+	}
+
+	return c.doRequest(ctx, "POST", "/events", nil, jBytes)
+}
+
+//Ping checks that SHOUT! can be reached and that the configured credentials
+// are accepted, by sending a HEAD request to the base of the target. It
+// returns nil if SHOUT! responds with a success status.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, "HEAD", "/", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	drainAndClose(resp.Body)
+	return nil
 }
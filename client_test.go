@@ -0,0 +1,311 @@
+package shout_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	shout "github.com/thomasmitchell/go-shout"
+)
+
+const brokenStateJSON = `{
+	"name": "my-topic",
+	"state": "broken",
+	"previous": {"occurred-at": 1500000000, "reported-at": 1500000001, "ok": true, "message": "all good"},
+	"first": {"occurred-at": 1500000100, "reported-at": 1500000101, "ok": false, "message": "disk full"},
+	"last": {"occurred-at": 1500000200, "reported-at": 1500000201, "ok": false, "message": "disk still full"}
+}`
+
+//newTestClient starts a server with the given handler, and returns a client
+// that targets it. The caller must close the server.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...shout.Option) (*shout.Client, *httptest.Server) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	client, err := shout.NewClient(server.URL, opts...)
+	if err != nil {
+		server.Close()
+		t.Fatalf("Could not create client: %s", err)
+	}
+
+	return client, server
+}
+
+//respondWith is a handler that answers every request with the given JSON body
+func respondWith(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}
+}
+
+func TestTargetTrailingSlash(t *testing.T) {
+	for _, suffix := range []string{"", "/"} {
+		var path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.RequestURI
+			respondWith(brokenStateJSON)(w, r)
+		}))
+		defer server.Close()
+
+		client, err := shout.NewClient(server.URL + suffix)
+		if err != nil {
+			t.Fatalf("Could not create client for target `%s': %s", server.URL+suffix, err)
+		}
+
+		_, err = client.PostEvent(shout.EventIn{Topic: "my-topic", Message: "disk full"})
+		if err != nil {
+			t.Fatalf("PostEvent with target `%s' failed: %s", server.URL+suffix, err)
+		}
+
+		if path != "/events" {
+			t.Errorf("With target `%s', expected request to /events, got `%s'", server.URL+suffix, path)
+		}
+	}
+}
+
+func TestPostEventEmptyResponse(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"204": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+		"empty 200": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+		},
+	}
+
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			client, server := newTestClient(t, handler)
+			defer server.Close()
+
+			state, err := client.PostEvent(shout.EventIn{Topic: "my-topic", Message: "disk full"})
+			if err != nil {
+				t.Fatalf("PostEvent failed: %s", err)
+			}
+
+			if state.Name != "my-topic" {
+				t.Errorf("Expected name `my-topic', got `%s'", state.Name)
+			}
+
+			if !state.IsBroken() {
+				t.Errorf("Expected the state implied by a broken event, got %s", state.State)
+			}
+
+			if !state.Last.OccurredAt.IsZero() || !state.First.OccurredAt.IsZero() || !state.Previous.OccurredAt.IsZero() {
+				t.Errorf("Expected no events, got %+v", state)
+			}
+
+			state, err = client.PostEvent(shout.EventIn{Topic: "my-topic", OK: true})
+			if err != nil {
+				t.Fatalf("PostEvent of a working event failed: %s", err)
+			}
+
+			if !state.State.OK() {
+				t.Errorf("Expected the state implied by a working event, got %s", state.State)
+			}
+
+			state, err = client.PostEvent(shout.EventIn{Topic: "my-topic", Informational: true})
+			if err != nil {
+				t.Fatalf("PostEvent of an informational event failed: %s", err)
+			}
+
+			if state.Name != "my-topic" || state.State != shout.TopicUnreported {
+				t.Errorf("Expected only the name for an informational event, got %+v", state)
+			}
+
+			state, err = client.Heartbeat("my-topic")
+			if err != nil {
+				t.Fatalf("Heartbeat failed: %s", err)
+			}
+
+			if state.State != shout.TopicUnreported || state.State.OK() {
+				t.Errorf("Expected a heartbeat to give an unreported state, got %s", state.State)
+			}
+		})
+	}
+}
+
+func TestBodilessRequests(t *testing.T) {
+	type request struct {
+		contentType   string
+		contentLength int64
+		body          []byte
+	}
+
+	var got request
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = request{
+			contentType:   r.Header.Get("Content-Type"),
+			contentLength: r.ContentLength,
+			body:          body,
+		}
+
+		switch {
+		case r.Method == "HEAD" || r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/topics":
+			respondWith("["+brokenStateJSON+"]")(w, r)
+		case r.URL.Path == "/topics/my-topic/events":
+			respondWith("[]")(w, r)
+		default:
+			respondWith(brokenStateJSON)(w, r)
+		}
+	}
+
+	client, server := newTestClient(t, handler)
+	defer server.Close()
+
+	calls := map[string]func() error{
+		"GetTopic": func() error {
+			_, err := client.GetTopic("my-topic")
+			return err
+		},
+		"ListTopics": func() error {
+			_, err := client.ListTopics()
+			return err
+		},
+		"GetEvents": func() error {
+			_, err := client.GetEvents("my-topic")
+			return err
+		},
+		"DeleteTopic": func() error {
+			return client.DeleteTopic("my-topic")
+		},
+		"Unsilence": func() error {
+			return client.Unsilence("my-topic")
+		},
+		"Ping": func() error {
+			return client.Ping(context.Background())
+		},
+	}
+
+	for name, call := range calls {
+		got = request{}
+		err := call()
+		if err != nil {
+			t.Errorf("%s failed: %s", name, err)
+			continue
+		}
+
+		if got.contentType != "" {
+			t.Errorf("%s: expected no Content-Type, got `%s'", name, got.contentType)
+		}
+
+		if got.contentLength != 0 || len(got.body) != 0 {
+			t.Errorf("%s: expected no body, got %d bytes with Content-Length %d", name, len(got.body), got.contentLength)
+		}
+	}
+}
+
+func TestPostEventReusesConnections(t *testing.T) {
+	//Trailing data after the JSON must still be read for the connection to be
+	// reused
+	server := httptest.NewUnstartedServer(respondWith(brokenStateJSON + "\n\n   \n"))
+	var conns int64
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client, err := shout.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Could not create client: %s", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		_, err := client.PostEvent(shout.EventIn{Topic: "my-topic", Message: "disk full"})
+		if err != nil {
+			t.Fatalf("PostEvent %d failed: %s", i, err)
+		}
+	}
+
+	if n := atomic.LoadInt64(&conns); n > 1 {
+		t.Errorf("Expected posting events one at a time to use one connection, but %d were opened", n)
+	}
+}
+
+//TestConcurrentUse uses one client from many goroutines with every feature that
+// keeps state between requests enabled. It is meant to be run with the race
+// detector, as with go test -race.
+func TestConcurrentUse(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "999")
+		respondWith(brokenStateJSON)(w, r)
+	}
+
+	//The primary target fails now and then, so that requests fail over to the
+	// fallback target
+	var requests int64
+	primary := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1)%7 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		handler(w, r)
+	}
+
+	fallback := httptest.NewServer(http.HandlerFunc(handler))
+	defer fallback.Close()
+
+	client, server := newTestClient(t, primary,
+		shout.WithTargets([]string{fallback.URL}),
+		shout.WithSuppressUnchanged(time.Minute),
+		shout.WithCircuitBreaker(3, time.Second),
+		shout.WithTopicCache(time.Minute),
+		shout.WithCoalescing(),
+		shout.WithRateLimit(100000, 1000),
+	)
+	defer server.Close()
+
+	const workers = 20
+	errs := make(chan error, workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			topic := fmt.Sprintf("topic-%d", i%4)
+			for j := 0; j < 25; j++ {
+				_, err := client.PostEvent(shout.EventIn{Topic: topic, Message: "disk full", OK: j%3 == 0})
+				if err != nil {
+					errs <- fmt.Errorf("PostEvent failed: %s", err)
+					return
+				}
+
+				_, err = client.GetTopic(topic)
+				if err != nil {
+					errs <- fmt.Errorf("GetTopic failed: %s", err)
+					return
+				}
+
+				client.LastRateLimit()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	info, ok := client.LastRateLimit()
+	if !ok || info.Limit != 1000 {
+		t.Errorf("Expected the last rate limit to have a limit of 1000, got %+v", info)
+	}
+}
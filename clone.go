@@ -0,0 +1,60 @@
+package shout
+
+import (
+	"net/http"
+	"time"
+)
+
+//Clone returns a copy of the client that can be changed without affecting the
+// original. Headers and middleware are copied, but the clone shares the
+// original's rate limiter, circuit breaker, failover tracking, suppression
+// record, topic cache, and in-flight posts, so that those apply across both.
+func (c *Client) Clone() *Client {
+	clone := *c
+	if c.Headers != nil {
+		clone.Headers = c.Headers.Clone()
+	}
+
+	clone.middleware = append([]func(http.RoundTripper) http.RoundTripper(nil), c.middleware...)
+	//The clone may be given different middleware or transport options, so it
+	// builds its own HTTP client
+	if c.clients != nil {
+		clone.clients = &httpClientCache{}
+	}
+	return &clone
+}
+
+//With returns a clone of the client with the given options applied. The
+// original client is never modified.
+func (c *Client) With(opts ...Option) (*Client, error) {
+	clone := c.Clone()
+	for _, opt := range opts {
+		err := opt(clone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return clone, nil
+}
+
+//WithTimeout returns a clone of the client with the given Timeout. The
+// original client is never modified.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	clone := c.Clone()
+	clone.Timeout = timeout
+	return clone
+}
+
+//WithHeader returns a clone of the client that also sends the given header
+// with every request, replacing any values already set for it. The original
+// client is never modified.
+func (c *Client) WithHeader(key, value string) *Client {
+	clone := c.Clone()
+	if clone.Headers == nil {
+		clone.Headers = http.Header{}
+	}
+
+	clone.Headers.Set(key, value)
+	return clone
+}
@@ -0,0 +1,87 @@
+package shout
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+//flightGroup collapses concurrent calls with the same key into one, so that
+// identical events posted at the same time share a single request
+type flightGroup struct {
+	lock    sync.Mutex
+	flights map[string]*flight
+}
+
+//flight is a call in progress, or just finished, for one key
+type flight struct {
+	done  chan struct{}
+	state *StateOut
+	err   error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: map[string]*flight{}}
+}
+
+//do calls fn, unless a call with the same key is already in progress, in which
+// case it waits for that call and returns its result instead. A caller that
+// waits stops waiting once its own context is done, and gets the context's
+// error. Each caller gets its own copy of the state.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (*StateOut, error)) (*StateOut, error) {
+	g.lock.Lock()
+	f, found := g.flights[key]
+	if !found {
+		f = &flight{done: make(chan struct{})}
+		g.flights[key] = f
+	}
+	g.lock.Unlock()
+
+	if !found {
+		f.state, f.err = fn()
+
+		g.lock.Lock()
+		delete(g.flights, key)
+		g.lock.Unlock()
+
+		close(f.done)
+	} else {
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	state := *f.state
+	return &state, nil
+}
+
+//coalesceKey returns the key that identifies events that are the same for the
+// purposes of coalescing. The occurrence time and idempotency key are left out,
+// because identical events posted at the same moment differ only in those.
+func coalesceKey(e EventIn) string {
+	key, _ := json.Marshal(struct {
+		Topic         string
+		Message       string
+		Link          string
+		OK            bool
+		Informational bool
+		Severity      Severity
+		Metadata      map[string]string
+	}{
+		Topic:         e.Topic,
+		Message:       e.Message,
+		Link:          e.Link,
+		OK:            e.OK,
+		Informational: e.Informational,
+		Severity:      e.Severity,
+		Metadata:      e.Metadata,
+	})
+
+	return string(key)
+}
@@ -0,0 +1,131 @@
+package shout
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+//gzipBytes returns b compressed with gzip
+func gzipBytes(b []byte) ([]byte, error) {
+	buf := bytes.Buffer{}
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//cloneHeader returns a copy of h that can be modified without affecting h. A
+// nil h gives an empty header.
+func cloneHeader(h http.Header) http.Header {
+	if h == nil {
+		return http.Header{}
+	}
+
+	return h.Clone()
+}
+
+//decompressResponse replaces the body of a gzip- or deflate-encoded response
+// with one that decompresses it. The transport of an http.Client only does this
+// on its own when it chose to ask for gzip itself, which it does not if an
+// Accept-Encoding header was set on the request, such as with WithHeader. Every
+// response goes through here, so that all methods decode the same way no matter
+// who asked for the encoding. Any other encoding is an error.
+func decompressResponse(resp *http.Response) error {
+	var (
+		r   io.Reader
+		err error
+	)
+
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		r, err = zlib.NewReader(resp.Body)
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("Unsupported Content-Encoding `%s'", resp.Header.Get("Content-Encoding"))
+	}
+
+	if err == io.EOF {
+		//An empty body is still empty when decompressed
+		r = nil
+	} else if err != nil {
+		resp.Body.Close()
+		return err
+	}
+
+	resp.Body = &decompressedBody{r: r, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+//defaultMaxResponseSize is used when Client.MaxResponseSize is unset
+const defaultMaxResponseSize = 4 * 1024 * 1024
+
+//limitedBody is a response body that fails with ErrResponseTooLarge once more
+// than a set number of bytes have been read from it
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	//Read one byte past the limit, so that a body of exactly the limit isn't
+	// mistaken for one that is too large
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.body.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrResponseTooLarge
+	}
+
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
+
+//decompressedBody reads a compressed response body, and closes the underlying
+// body when closed
+type decompressedBody struct {
+	r    io.Reader
+	body io.ReadCloser
+}
+
+func (d *decompressedBody) Read(p []byte) (int, error) {
+	if d.r == nil {
+		return 0, io.EOF
+	}
+
+	return d.r.Read(p)
+}
+
+func (d *decompressedBody) Close() error {
+	return d.body.Close()
+}
@@ -0,0 +1,134 @@
+package shout_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"testing"
+
+	shout "github.com/thomasmitchell/go-shout"
+)
+
+//compressed is a handler that answers every request with the given JSON body,
+// compressed with the given encoding no matter what the request asked for
+func compressed(encoding, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buf := bytes.Buffer{}
+		var zw io.WriteCloser
+		switch encoding {
+		case "gzip":
+			zw = gzip.NewWriter(&buf)
+		case "deflate":
+			zw = zlib.NewWriter(&buf)
+		}
+		zw.Write([]byte(body))
+		zw.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", encoding)
+		w.Write(buf.Bytes())
+	}
+}
+
+func TestGzipStateResponse(t *testing.T) {
+	//Asking for gzip ourselves stops the transport from decompressing it
+	client, server := newTestClient(t, compressed("gzip", brokenStateJSON),
+		shout.WithHeader("Accept-Encoding", "gzip"))
+	defer server.Close()
+
+	state, err := client.PostEvent(shout.EventIn{Topic: "my-topic", Message: "disk still full"})
+	if err != nil {
+		t.Fatalf("PostEvent failed: %s", err)
+	}
+
+	if state.Name != "my-topic" {
+		t.Errorf("Expected name `my-topic', got `%s'", state.Name)
+	}
+
+	if !state.IsBroken() {
+		t.Errorf("Expected state broken, got %s", state.State)
+	}
+
+	if state.Last.Message != "disk still full" {
+		t.Errorf("Expected last message `disk still full', got `%s'", state.Last.Message)
+	}
+}
+
+func TestDecompressResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		//manual is true if the client asks for the encoding itself, instead of
+		// leaving it to the transport
+		manual bool
+		//compressRequests is true if request bodies are compressed too
+		compressRequests bool
+	}{
+		{name: "transparent gzip", encoding: "gzip"},
+		{name: "manual gzip", encoding: "gzip", manual: true},
+		{name: "manual deflate", encoding: "deflate", manual: true},
+		{name: "transparent gzip with request compression", encoding: "gzip", compressRequests: true},
+		{name: "manual gzip with request compression", encoding: "gzip", manual: true, compressRequests: true},
+	}
+
+	bodies := map[string]string{
+		"/events":                 brokenStateJSON,
+		"/topics":                 "[" + brokenStateJSON + "]",
+		"/topics/my-topic/events": `[{"occurred-at": 1500000200, "ok": false, "message": "disk still full"}]`,
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var acceptEncoding string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				acceptEncoding = r.Header.Get("Accept-Encoding")
+				compressed(test.encoding, bodies[r.URL.Path])(w, r)
+			}
+
+			opts := []shout.Option{}
+			if test.manual {
+				opts = append(opts, shout.WithHeader("Accept-Encoding", test.encoding))
+			}
+
+			if test.compressRequests {
+				opts = append(opts, shout.WithRequestCompression(1))
+			}
+
+			client, server := newTestClient(t, handler, opts...)
+			defer server.Close()
+
+			state, err := client.PostEvent(shout.EventIn{Topic: "my-topic", Message: "disk still full"})
+			if err != nil {
+				t.Fatalf("PostEvent failed: %s", err)
+			}
+
+			if state.Last.Message != "disk still full" {
+				t.Errorf("PostEvent: expected last message `disk still full', got `%s'", state.Last.Message)
+			}
+
+			if !test.manual && acceptEncoding != "gzip" {
+				t.Errorf("Expected the transport to ask for gzip, got Accept-Encoding `%s'", acceptEncoding)
+			}
+
+			topics, err := client.ListTopics()
+			if err != nil {
+				t.Fatalf("ListTopics failed: %s", err)
+			}
+
+			if len(topics) != 1 || topics[0].Name != "my-topic" {
+				t.Errorf("ListTopics: expected only topic `my-topic', got %v", topics)
+			}
+
+			events, err := client.GetEvents("my-topic")
+			if err != nil {
+				t.Fatalf("GetEvents failed: %s", err)
+			}
+
+			if len(events) != 1 || events[0].Message != "disk still full" {
+				t.Errorf("GetEvents: expected one event `disk still full', got %v", events)
+			}
+		})
+	}
+}
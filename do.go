@@ -0,0 +1,42 @@
+package shout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+//Do sends a request to an endpoint of SHOUT! that this package has no method
+// for, and is meant for advanced usage only. The path is relative to the
+// target and base path, and may include a query string. If body is non-nil,
+// it is marshalled as JSON and sent as the body of the request. The request
+// goes through the same authentication, headers, retries, rate limiting, and
+// status checks as any other; a failure status gives an *APIError. If out is
+// non-nil, the body of the response is decoded into it as JSON, unless the
+// response has a 204 No Content status.
+//
+//Prefer the dedicated methods where they exist, since the paths and bodies
+// that Do is given are not checked by this package.
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var jBytes []byte
+	if body != nil {
+		var err error
+		jBytes, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("Could not marshal request body as JSON: %s", err)
+		}
+	}
+
+	resp, err := c.doRequest(ctx, method, path, nil, jBytes)
+	if err != nil {
+		return err
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		drainAndClose(resp.Body)
+		return nil
+	}
+
+	return c.decodeJSON(resp, out)
+}
@@ -0,0 +1,212 @@
+package shout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//ErrTopicNotFound is returned when a topic is requested that SHOUT! does not
+// know about
+var ErrTopicNotFound = errors.New("Topic not found")
+
+//ErrCircuitOpen is returned without sending a request when the circuit breaker
+// set up with WithCircuitBreaker has tripped
+var ErrCircuitOpen = errors.New("Circuit breaker is open; not contacting SHOUT!")
+
+//ErrResponseTooLarge is returned when the body of a response from SHOUT! is
+// larger than Client.MaxResponseSize
+var ErrResponseTooLarge = errors.New("Response from SHOUT! is too large")
+
+//ErrDryRun is returned in place of sending a request when Client.DryRun is set
+var ErrDryRun = errors.New("Dry run; nothing was sent to SHOUT!")
+
+//ErrInvalidResponse is matched by errors.Is when a successful response from
+// SHOUT! could not be decoded. The error is an *InvalidResponseError, which has
+// the body that was received.
+var ErrInvalidResponse = errors.New("Invalid response from SHOUT!")
+
+//maxErrorBodySize is the most of an error response body that will be kept on
+// an APIError
+const maxErrorBodySize = 64 * 1024
+
+//APIError is returned when SHOUT! responds with a status code that indicates
+// failure
+type APIError struct {
+	//StatusCode is the numeric HTTP status code of the response
+	StatusCode int
+	//Status is the HTTP status line of the response, e.g. "404 Not Found"
+	Status string
+	//Body is the body of the response, truncated to 64KiB
+	Body []byte
+	//Message is the error message given by SHOUT! in the body of the response,
+	// if the body was a JSON object with an "error" field
+	Message string
+	//RetryAfter is the delay that SHOUT! asked for before the request is
+	// retried, from the Retry-After header. It is zero if there was none.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("SHOUT! returned non-2xx status code: %s: %s", e.Status, e.Message)
+	}
+
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("SHOUT! returned non-2xx status code: %s", e.Status)
+	}
+
+	return fmt.Sprintf("SHOUT! returned non-2xx status code: %s: %s", e.Status, e.Body)
+}
+
+//InvalidResponseError is returned when SHOUT! responds with a success status,
+// but the body of the response could not be decoded. This is commonly because
+// something between the client and SHOUT!, such as a proxy or load balancer,
+// answered with a page of its own.
+type InvalidResponseError struct {
+	//StatusCode is the numeric HTTP status code of the response
+	StatusCode int
+	//ContentType is the Content-Type header of the response
+	ContentType string
+	//Body is the body of the response, truncated to 64KiB
+	Body []byte
+	//Err is the error from decoding the body
+	Err error
+}
+
+func (e *InvalidResponseError) Error() string {
+	if e.ContentType == "" {
+		return fmt.Sprintf("Could not parse response as JSON: %s", e.Err)
+	}
+
+	return fmt.Sprintf("Could not parse response of type `%s' as JSON: %s", e.ContentType, e.Err)
+}
+
+func (e *InvalidResponseError) Unwrap() error {
+	return e.Err
+}
+
+//Is makes errors.Is(err, ErrInvalidResponse) true
+func (e *InvalidResponseError) Is(target error) bool {
+	return target == ErrInvalidResponse
+}
+
+//IsClientError returns true if err is or wraps an APIError with a 4xx status
+// code, meaning that SHOUT! rejected the request as it was sent. Repeating the
+// same request will not succeed.
+func IsClientError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+//IsServerError returns true if err is or wraps an APIError with a 5xx status
+// code, meaning that SHOUT! failed to handle a request that may succeed later
+func IsServerError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500 && apiErr.StatusCode < 600
+}
+
+//IsNotFound returns true if err is or wraps ErrTopicNotFound, or an APIError
+// with a 404 status code
+func IsNotFound(err error) bool {
+	if errors.Is(err, ErrTopicNotFound) {
+		return true
+	}
+
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+//parseErrorMessage returns the message from a SHOUT! error body of the form
+// {"error":"..."}, or an empty string if the body isn't of that form
+func parseErrorMessage(body []byte) string {
+	envelope := struct {
+		Error string `json:"error"`
+	}{}
+
+	err := json.Unmarshal(body, &envelope)
+	if err != nil {
+		return ""
+	}
+
+	return envelope.Error
+}
+
+//FieldProblem is one reason that an event failed validation
+type FieldProblem struct {
+	//Field is the name of the EventIn field with the problem, e.g. "Link"
+	Field string
+	//Reason describes the problem
+	Reason string
+}
+
+//ValidationError is returned by EventIn.Validate, and by anything that
+// validates an event before sending it, listing every problem with the event
+type ValidationError struct {
+	Problems []FieldProblem
+}
+
+func (e *ValidationError) add(field, reason string) {
+	e.Problems = append(e.Problems, FieldProblem{Field: field, Reason: reason})
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].Reason
+	}
+
+	reasons := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		reasons = append(reasons, p.Reason)
+	}
+
+	return fmt.Sprintf("Event has %d problems: %s", len(e.Problems), strings.Join(reasons, "; "))
+}
+
+//Fields returns the names of the fields with problems, each listed once, in
+// the order they were found
+func (e *ValidationError) Fields() []string {
+	seen := map[string]bool{}
+	fields := []string{}
+	for _, p := range e.Problems {
+		if !seen[p.Field] {
+			seen[p.Field] = true
+			fields = append(fields, p.Field)
+		}
+	}
+
+	return fields
+}
+
+//BatchFailure describes one item of a batch that could not be posted
+type BatchFailure struct {
+	//Index is the position of the item in the batch
+	Index int
+	//Topic is the topic of the item
+	Topic string
+	//Err is the reason the item could not be posted
+	Err error
+}
+
+//BatchError is returned when some items of a batch could not be posted. Items
+// not listed in Failures were posted successfully.
+type BatchError struct {
+	Failures []BatchFailure
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Failures) == 1 {
+		f := e.Failures[0]
+		return fmt.Sprintf("Could not post to topic `%s': %s", f.Topic, f.Err)
+	}
+
+	topics := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		topics = append(topics, fmt.Sprintf("`%s'", f.Topic))
+	}
+
+	return fmt.Sprintf("Could not post to %d topics: %s", len(e.Failures), strings.Join(topics, ", "))
+}
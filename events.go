@@ -0,0 +1,329 @@
+package shout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//EventsOption narrows or orders the events returned by GetEvents
+type EventsOption func(*eventsQuery)
+
+type eventsQuery struct {
+	since       time.Time
+	until       time.Time
+	limit       int
+	offset      int
+	oldestFirst bool
+}
+
+//EventsSince limits GetEvents to events that occurred at or after t
+func EventsSince(t time.Time) EventsOption {
+	return func(q *eventsQuery) { q.since = t }
+}
+
+//EventsUntil limits GetEvents to events that occurred before t
+func EventsUntil(t time.Time) EventsOption {
+	return func(q *eventsQuery) { q.until = t }
+}
+
+//EventsLimit limits GetEvents to returning at most n events. Combined with
+// the order, this returns the n newest or n oldest events.
+func EventsLimit(n int) EventsOption {
+	return func(q *eventsQuery) { q.limit = n }
+}
+
+//EventsOffset makes GetEvents skip the first n events that it would otherwise
+// return, so that a long history can be fetched a page at a time. See also
+// IterateEvents.
+func EventsOffset(n int) EventsOption {
+	return func(q *eventsQuery) { q.offset = n }
+}
+
+//EventsOldestFirst makes GetEvents return events in the order they occurred,
+// instead of the default of newest first
+func EventsOldestFirst() EventsOption {
+	return func(q *eventsQuery) { q.oldestFirst = true }
+}
+
+func (q eventsQuery) values(millis bool) url.Values {
+	v := url.Values{}
+	if !q.since.IsZero() {
+		v.Set("since", strconv.FormatInt(toEpoch(q.since, millis), 10))
+	}
+
+	if !q.until.IsZero() {
+		v.Set("until", strconv.FormatInt(toEpoch(q.until, millis), 10))
+	}
+
+	if q.limit > 0 {
+		v.Set("limit", strconv.Itoa(q.limit))
+	}
+
+	if q.offset > 0 {
+		v.Set("offset", strconv.Itoa(q.offset))
+	}
+
+	if q.oldestFirst {
+		v.Set("order", "asc")
+	} else {
+		v.Set("order", "desc")
+	}
+
+	return v
+}
+
+//GetEvents fetches the history of events for the topic with the given name.
+// By default, events are returned newest first, and every event is returned;
+// options can be given to change the order, or to bound the time range or
+// number of events. If SHOUT! does not know of the topic, ErrTopicNotFound is
+// returned.
+func (c *Client) GetEvents(topic string, opts ...EventsOption) ([]EventOut, error) {
+	return c.GetEventsCtx(c.context(), topic, opts...)
+}
+
+//GetEventsCtx is GetEvents, but the request is bound to the given context
+func (c *Client) GetEventsCtx(ctx context.Context, topic string, opts ...EventsOption) ([]EventOut, error) {
+	q := eventsQuery{}
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	path := topicPath(topic) + "/events?" + q.values(c.MillisecondTimestamps).Encode()
+	resp, err := c.doRequest(ctx, "GET", path, nil, nil)
+	if err != nil {
+		return nil, topicError(err)
+	}
+
+	raws := []eventRaw{}
+	err = c.decodeJSON(resp, &raws)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]EventOut, 0, len(raws))
+	for _, raw := range raws {
+		ret = append(ret, parseEvent(raw, c.MillisecondTimestamps))
+	}
+
+	//Don't rely on the server to have honored the requested order
+	sort.SliceStable(ret, func(i, j int) bool {
+		if q.oldestFirst {
+			return ret[i].OccurredAt.Before(ret[j].OccurredAt)
+		}
+
+		return ret[i].OccurredAt.After(ret[j].OccurredAt)
+	})
+
+	return ret, nil
+}
+
+//defaultEventsPageSize is the number of events fetched per request by an
+// EventIterator if no page size is given
+const defaultEventsPageSize = 100
+
+//EventIterator steps through the event history of a topic, fetching it from
+// SHOUT! a page at a time so that the whole history never needs to be held in
+// memory. Use it like:
+//
+//	it := client.IterateEvents(ctx, "my-topic", 0)
+//	for it.Next() {
+//		e := it.Event()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type EventIterator struct {
+	client   *Client
+	ctx      context.Context
+	topic    string
+	opts     []EventsOption
+	pageSize int
+
+	page    []EventOut
+	current EventOut
+	offset  int
+	done    bool
+	err     error
+}
+
+//IterateEvents returns an EventIterator over the event history of the topic
+// with the given name, fetching pageSize events per request. A pageSize of
+// zero or less uses a default of 100. The options are the same as those given
+// to GetEvents; an EventsLimit bounds the size of each page, not the total
+// number of events, so a limit smaller than pageSize is used as the page size
+// instead, and an EventsOffset sets where the first page starts. The given
+// context bounds every request the iterator makes.
+func (c *Client) IterateEvents(ctx context.Context, topic string, pageSize int, opts ...EventsOption) *EventIterator {
+	if pageSize <= 0 {
+		pageSize = defaultEventsPageSize
+	}
+
+	q := eventsQuery{}
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	if q.limit > 0 && q.limit < pageSize {
+		pageSize = q.limit
+	}
+
+	return &EventIterator{
+		client:   c,
+		ctx:      ctx,
+		topic:    topic,
+		opts:     opts,
+		pageSize: pageSize,
+		offset:   q.offset,
+	}
+}
+
+//Next advances the iterator to the next event, fetching another page from
+// SHOUT! if needed. It returns false when there are no more events, or when an
+// error occurs, which can then be retrieved with Err.
+func (it *EventIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		opts := append(append([]EventsOption(nil), it.opts...),
+			EventsLimit(it.pageSize),
+			EventsOffset(it.offset),
+		)
+
+		it.page, it.err = it.client.GetEventsCtx(it.ctx, it.topic, opts...)
+		if it.err != nil {
+			it.page = nil
+			return false
+		}
+
+		it.offset += len(it.page)
+		if len(it.page) < it.pageSize {
+			it.done = true
+		}
+
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+//Event returns the event that the last call to Next advanced to
+func (it *EventIterator) Event() EventOut {
+	return it.current
+}
+
+//Err returns the error that caused Next to return false, if any. It returns
+// nil if Next stopped because the history was exhausted.
+func (it *EventIterator) Err() error {
+	return it.err
+}
+
+//EventUpdate changes one field of an event amended with UpdateEvent
+type EventUpdate func(*eventPatch)
+
+type eventPatch struct {
+	Message  *string           `json:"message,omitempty"`
+	Link     *string           `json:"link,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+//UpdateMessage replaces the message of the event
+func UpdateMessage(message string) EventUpdate {
+	return func(p *eventPatch) { p.Message = &message }
+}
+
+//UpdateLink replaces the link of the event. An empty link removes it.
+func UpdateLink(link string) EventUpdate {
+	return func(p *eventPatch) { p.Link = &link }
+}
+
+//UpdateMetadata sets the given metadata entries on the event, leaving any
+// other entries as they are
+func UpdateMetadata(metadata map[string]string) EventUpdate {
+	return func(p *eventPatch) {
+		if p.Metadata == nil {
+			p.Metadata = map[string]string{}
+		}
+
+		for k, v := range metadata {
+			p.Metadata[k] = v
+		}
+	}
+}
+
+//UpdateEvent amends an event that was already posted to the topic, such as to
+// add a runbook link to it after the fact, by sending a PATCH for it. The event
+// is identified by the time that it occurred, as given by its OccurredAt. Only
+// the fields given by the updates are changed; whether the event was working
+// or broken cannot be. The event as amended is returned. If SHOUT! does not
+// know of the topic or event, ErrTopicNotFound is returned.
+func (c *Client) UpdateEvent(topic string, occurredAt time.Time, updates ...EventUpdate) (*EventOut, error) {
+	return c.UpdateEventCtx(c.context(), topic, occurredAt, updates...)
+}
+
+//UpdateEventCtx is UpdateEvent, but the request is bound to the given context
+func (c *Client) UpdateEventCtx(ctx context.Context, topic string, occurredAt time.Time, updates ...EventUpdate) (*EventOut, error) {
+	if occurredAt.IsZero() {
+		return nil, fmt.Errorf("Event to update has no occurrence time")
+	}
+
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("No updates given for event")
+	}
+
+	patch := eventPatch{}
+	for _, update := range updates {
+		update(&patch)
+	}
+
+	if patch.Message != nil {
+		message, err := c.limitMessage(*patch.Message)
+		if err != nil {
+			return nil, err
+		}
+		patch.Message = &message
+	}
+
+	if patch.Link != nil && *patch.Link != "" && !c.LenientLinks {
+		err := validateLink(*patch.Link)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	jBytes, err := json.Marshal(&patch)
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal event update as JSON: %s", err)
+	}
+
+	path := topicPath(topic) + "/events/" + strconv.FormatInt(toEpoch(occurredAt, c.MillisecondTimestamps), 10)
+	resp, err := c.doRequest(ctx, "PATCH", path, nil, jBytes)
+	c.topicChanged(topic)
+	if err != nil {
+		return nil, topicError(err)
+	}
+
+	raw := eventRaw{}
+	err = c.decodeJSON(resp, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	event := parseEvent(raw, c.MillisecondTimestamps)
+	return &event, nil
+}
@@ -0,0 +1,110 @@
+package shout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	//failoverThreshold is the number of consecutive failures after which a
+	// target is skipped
+	failoverThreshold = 3
+	//failoverCooldown is how long a target is skipped for
+	failoverCooldown = 30 * time.Second
+)
+
+//targetSet tracks the health of a list of targets, so that ones that keep
+// failing can be skipped for a while
+type targetSet struct {
+	targets []string
+
+	lock      sync.Mutex
+	failures  []int
+	skipUntil []time.Time
+}
+
+func newTargetSet(targets []string) *targetSet {
+	return &targetSet{
+		targets:   targets,
+		failures:  make([]int, len(targets)),
+		skipUntil: make([]time.Time, len(targets)),
+	}
+}
+
+//order returns the indices of the targets in the order they should be tried:
+// healthy targets first, in the order they were configured, followed by
+// skipped targets, in case every target has been skipped
+func (t *targetSet) order(now time.Time) []int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	healthy := make([]int, 0, len(t.targets))
+	skipped := []int{}
+	for i := range t.targets {
+		if now.Before(t.skipUntil[i]) {
+			skipped = append(skipped, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+
+	return append(healthy, skipped...)
+}
+
+func (t *targetSet) succeeded(i int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.failures[i] = 0
+	t.skipUntil[i] = time.Time{}
+}
+
+func (t *targetSet) failed(i int, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.failures[i]++
+	if t.failures[i] >= failoverThreshold {
+		t.skipUntil[i] = now.Add(failoverCooldown)
+	}
+}
+
+//shouldFailover returns true if the error means that another target might do
+// better: if the request didn't reach SHOUT!, or SHOUT! had a server error
+func shouldFailover(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+
+	return isTransportError(err)
+}
+
+//doFailover makes an attempt at sending a request, trying each target in turn
+// until one does not fail in a way that suggests it is unhealthy
+func (c *Client) doFailover(ctx context.Context, attempt int, method, path string, header http.Header, body []byte) (*http.Response, error) {
+	if c.failover == nil {
+		return c.doAttempt(ctx, attempt, c.Target, method, path, header, body)
+	}
+
+	var err error
+	for _, i := range c.failover.order(c.now()) {
+		var resp *http.Response
+		resp, err = c.doAttempt(ctx, attempt, c.failover.targets[i], method, path, header, body)
+		if ctx.Err() != nil {
+			return resp, err
+		}
+
+		if err == nil || !shouldFailover(err) {
+			c.failover.succeeded(i)
+			return resp, err
+		}
+
+		c.failover.failed(i, c.now())
+	}
+
+	return nil, err
+}
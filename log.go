@@ -0,0 +1,93 @@
+package shout
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//RequestLog describes a single attempt at sending a request to SHOUT!
+type RequestLog struct {
+	//Method is the HTTP method of the request
+	Method string
+	//Path is the path of the request, relative to the target
+	Path string
+	//Attempt is 1 for the first attempt at the request, 2 for the first retry,
+	// and so on
+	Attempt int
+	//Header holds the headers that were sent, with credentials redacted
+	Header http.Header
+	//StatusCode is the status code of the response, or zero if none was
+	// received
+	StatusCode int
+	//Duration is how long it took to receive the response headers, or to fail
+	Duration time.Duration
+	//Err is the error that prevented a response from being received, if any.
+	// Responses with error status codes are not counted here.
+	Err error
+}
+
+//Logger receives a RequestLog for every attempt at a request that a Client
+// makes. It must be safe to call from multiple goroutines at once.
+type Logger interface {
+	LogRequest(ctx context.Context, entry RequestLog)
+}
+
+//LoggerFunc allows a plain function to be used as a Logger
+type LoggerFunc func(ctx context.Context, entry RequestLog)
+
+//LogRequest calls f(ctx, entry)
+func (f LoggerFunc) LogRequest(ctx context.Context, entry RequestLog) {
+	f(ctx, entry)
+}
+
+//Propagator adds headers derived from a request's context to the request, so
+// that distributed traces can continue through SHOUT!. An OpenTelemetry
+// TextMapPropagator can be adapted to this by wrapping the header in a
+// propagation.HeaderCarrier.
+type Propagator interface {
+	Inject(ctx context.Context, header http.Header)
+}
+
+//PropagatorFunc allows a plain function to be used as a Propagator
+type PropagatorFunc func(ctx context.Context, header http.Header)
+
+//Inject calls f(ctx, header)
+func (f PropagatorFunc) Inject(ctx context.Context, header http.Header) {
+	f(ctx, header)
+}
+
+//redactedHeaders are the headers whose values are never given to a Logger
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+func redactHeader(h http.Header) http.Header {
+	ret := make(http.Header, len(h))
+	for k, v := range h {
+		ret[k] = append([]string(nil), v...)
+	}
+
+	for _, k := range redactedHeaders {
+		if _, found := ret[k]; found {
+			ret[k] = []string{"REDACTED"}
+		}
+	}
+
+	return ret
+}
+
+func (c *Client) logRequest(ctx context.Context, req *http.Request, path string, attempt int, resp *http.Response, err error, duration time.Duration) {
+	entry := RequestLog{
+		Method:   req.Method,
+		Path:     path,
+		Attempt:  attempt,
+		Header:   redactHeader(req.Header),
+		Duration: duration,
+		Err:      err,
+	}
+
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+
+	c.Logger.LogRequest(ctx, entry)
+}
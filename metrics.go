@@ -0,0 +1,71 @@
+package shout
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//Metrics receives measurements of the requests that a Client makes, so that
+// they can be recorded by a metrics library. Each logical call, such as a
+// single PostEvent, is reported once with ObserveCall, while each attempt at
+// sending its request, including retries, is reported with ObserveAttempt.
+//
+//The route given to each function is the path of the request with any topic
+// name replaced by "{name}", so that it can be used as a metric label. The
+// status class is one of "2xx", "3xx", "4xx", "5xx", or "error" if no response
+// was received. Implementations must be safe to call from multiple goroutines
+// at once.
+type Metrics interface {
+	ObserveAttempt(method, route, statusClass string, duration time.Duration)
+	ObserveCall(method, route, statusClass string, attempts int, duration time.Duration)
+}
+
+//routeOf returns the path with any topic name or event time replaced by a
+// placeholder
+func routeOf(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts)-1; i++ {
+		if parts[i] == "topics" {
+			parts[i+1] = "{name}"
+			//An event of a topic is named by when it occurred
+			if i+3 < len(parts) && parts[i+2] == "events" {
+				parts[i+3] = "{occurred-at}"
+			}
+			break
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+//statusClass returns the status class of the response to a request
+func statusClass(resp *http.Response, err error) string {
+	code := 0
+	if resp != nil {
+		code = resp.StatusCode
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		code = apiErr.StatusCode
+	}
+
+	switch {
+	case code >= 200 && code < 300:
+		return "2xx"
+	case code >= 300 && code < 400:
+		return "3xx"
+	case code >= 400 && code < 500:
+		return "4xx"
+	case code >= 500 && code < 600:
+		return "5xx"
+	}
+
+	return "error"
+}
@@ -0,0 +1,650 @@
+package shout
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//Option configures a Client created with NewClient
+type Option func(*Client) error
+
+//WithHTTPClient sets the net/http client that will be used to send requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) error {
+		if client == nil {
+			return fmt.Errorf("HTTP client cannot be nil")
+		}
+
+		c.HTTPClient = client
+		return nil
+	}
+}
+
+//WithTargets adds fallback targets to try, in order, when a request to the
+// target given to NewClient fails to connect or gets a 5xx response. A target
+// that fails several times in a row is skipped for a while, unless every
+// target is failing.
+func WithTargets(targets []string) Option {
+	return func(c *Client) error {
+		all := []string{c.Target}
+		for _, target := range targets {
+			err := validateTarget(target)
+			if err != nil {
+				return err
+			}
+
+			all = append(all, strings.TrimRight(target, "/"))
+		}
+
+		c.failover = newTargetSet(all)
+		return nil
+	}
+}
+
+//WithCircuitBreaker stops the client from contacting SHOUT! after threshold
+// consecutive requests fail to connect or get a 5xx response. While the circuit
+// is open, requests fail immediately with ErrCircuitOpen. After cooldown has
+// passed, one request is let through as a probe: if it succeeds, requests flow
+// normally again, and otherwise the cooldown starts over. Each retry counts as
+// a request.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) error {
+		if threshold < 1 {
+			return fmt.Errorf("Circuit breaker threshold must be at least 1")
+		}
+
+		if cooldown <= 0 {
+			return fmt.Errorf("Circuit breaker cooldown must be positive")
+		}
+
+		c.breaker = newCircuitBreaker(threshold, cooldown)
+		return nil
+	}
+}
+
+//WithBasePath sets a path prefix that SHOUT! is served under, such as /api/v2
+func WithBasePath(path string) Option {
+	return func(c *Client) error {
+		c.BasePath = normalizeBasePath(path)
+		return nil
+	}
+}
+
+//WithRequestCompression causes request bodies larger than threshold bytes to
+// be compressed with gzip before they are sent. A threshold of zero disables
+// compression.
+func WithRequestCompression(threshold int) Option {
+	return func(c *Client) error {
+		if threshold < 0 {
+			return fmt.Errorf("Compression threshold cannot be negative")
+		}
+
+		c.CompressionThreshold = threshold
+		return nil
+	}
+}
+
+//WithMillisecondTimestamps makes the client send and read times as
+// milliseconds since the epoch, for SHOUT! servers that expect them
+func WithMillisecondTimestamps() Option {
+	return func(c *Client) error {
+		c.MillisecondTimestamps = true
+		return nil
+	}
+}
+
+//WithMaxResponseSize sets the largest response body that will be read
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) error {
+		if size <= 0 {
+			return fmt.Errorf("Max response size must be positive")
+		}
+
+		c.MaxResponseSize = size
+		return nil
+	}
+}
+
+//WithClientCert sets a certificate to present to SHOUT! for mutual TLS. It may
+// be given multiple times to offer several certificates. Like the other
+// transport options, it has no effect if an HTTP client is given with
+// WithHTTPClient; configure the transport of that client instead.
+func WithClientCert(cert tls.Certificate) Option {
+	return func(c *Client) error {
+		c.modifyTLSConfig(func(cfg *tls.Config) {
+			cfg.Certificates = append(cfg.Certificates, cert)
+		})
+		return nil
+	}
+}
+
+//WithRootCAs sets the certificate authorities trusted to sign the certificate
+// of SHOUT!, in place of the system's. It has no effect if an HTTP client is
+// given with WithHTTPClient.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *Client) error {
+		if pool == nil {
+			return fmt.Errorf("Root CA pool cannot be nil")
+		}
+
+		c.modifyTLSConfig(func(cfg *tls.Config) {
+			cfg.RootCAs = pool
+		})
+		return nil
+	}
+}
+
+//WithInsecureSkipVerify disables verification of the certificate of SHOUT!.
+//
+//WARNING: This makes the connection open to interception by anyone on the
+// network path, and must only be used for local development against a server
+// with a self-signed certificate. Never use it in production.
+//
+//It has no effect if an HTTP client is given with WithHTTPClient.
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) error {
+		c.modifyTLSConfig(func(cfg *tls.Config) {
+			cfg.InsecureSkipVerify = true
+		})
+		return nil
+	}
+}
+
+//WithMaxIdleConns sets the most idle connections kept open across all hosts.
+// Unless an HTTP client is given with WithHTTPClient, this defaults to 100,
+// rather than the standard library's default. It has no effect if an HTTP
+// client is given.
+func WithMaxIdleConns(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("Max idle connections cannot be negative")
+		}
+
+		c.modifyTransport(func(t *http.Transport) {
+			t.MaxIdleConns = n
+		})
+		return nil
+	}
+}
+
+//WithMaxIdleConnsPerHost sets the most idle connections kept open to each
+// host. Unless an HTTP client is given with WithHTTPClient, this defaults to
+// 32, rather than the standard library's default of 2. It has no effect if an
+// HTTP client is given.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("Max idle connections per host cannot be negative")
+		}
+
+		c.modifyTransport(func(t *http.Transport) {
+			t.MaxIdleConnsPerHost = n
+		})
+		return nil
+	}
+}
+
+//WithProxy sends requests through the HTTP proxy at the given URL, instead of
+// whichever proxy is named by the environment variables. The URL must have an
+// http, https, or socks5 scheme. An empty URL disables proxying entirely. It
+// has no effect if an HTTP client is given with WithHTTPClient.
+func WithProxy(proxyURL string) Option {
+	return func(c *Client) error {
+		if proxyURL == "" {
+			c.modifyTransport(func(t *http.Transport) {
+				t.Proxy = nil
+			})
+			return nil
+		}
+
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("Could not parse proxy URL: %s", err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("Unsupported proxy URL scheme `%s'", u.Scheme)
+		}
+
+		if u.Host == "" {
+			return fmt.Errorf("Proxy URL `%s' has no host", proxyURL)
+		}
+
+		c.modifyTransport(func(t *http.Transport) {
+			t.Proxy = http.ProxyURL(u)
+		})
+		return nil
+	}
+}
+
+//WithTimeout sets the time limit for each request. It has no effect if an
+// HTTP client is also given with WithHTTPClient; set the Timeout of that
+// client instead
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("Timeout cannot be negative")
+		}
+
+		c.Timeout = timeout
+		return nil
+	}
+}
+
+//WithUserAgent sets the User-Agent header sent with each request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) error {
+		c.UserAgent = userAgent
+		return nil
+	}
+}
+
+//WithContentType sets the media type sent as the Content-Type of requests.
+// See Client.ContentType.
+func WithContentType(mediaType string) Option {
+	return func(c *Client) error {
+		_, _, err := mime.ParseMediaType(mediaType)
+		if err != nil {
+			return fmt.Errorf("Could not parse content type `%s': %s", mediaType, err)
+		}
+
+		c.ContentType = mediaType
+		return nil
+	}
+}
+
+//WithAccept sets the media types sent as the Accept header of requests. See
+// Client.Accept.
+func WithAccept(mediaTypes string) Option {
+	return func(c *Client) error {
+		if strings.TrimSpace(mediaTypes) == "" {
+			return fmt.Errorf("Accept header cannot be empty")
+		}
+
+		c.Accept = mediaTypes
+		return nil
+	}
+}
+
+//WithAPIVersion pins requests to the given version of the SHOUT! API. See
+// Client.APIVersion.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) error {
+		if version == "" || strings.ContainsAny(version, " \t,;\"") {
+			return fmt.Errorf("Invalid API version `%s'", version)
+		}
+
+		c.APIVersion = version
+		return nil
+	}
+}
+
+//WithMaxRetries sets the number of times a failed request will be retried.
+// See Client.MaxRetries for which failures are retried.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("Max retries cannot be negative")
+		}
+
+		c.MaxRetries = n
+		return nil
+	}
+}
+
+//WithBackoff sets the delay before the first retry, and the longest delay
+// that will be waited between any two retries
+func WithBackoff(base, max time.Duration) Option {
+	return func(c *Client) error {
+		if base <= 0 || max <= 0 {
+			return fmt.Errorf("Backoff durations must be positive")
+		}
+
+		if base > max {
+			return fmt.Errorf("Base backoff cannot be greater than max backoff")
+		}
+
+		c.RetryBackoff = base
+		c.MaxRetryBackoff = max
+		return nil
+	}
+}
+
+//WithRateLimit limits the client to sending requestsPerSecond requests per
+// second on average, allowing bursts of up to burst requests. Requests that
+// would exceed the limit wait until they are allowed, or until their context
+// is done. Retries count against the limit.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) error {
+		if requestsPerSecond <= 0 {
+			return fmt.Errorf("Rate limit must be positive")
+		}
+
+		if burst < 1 {
+			return fmt.Errorf("Rate limit burst must be at least 1")
+		}
+
+		c.limiter = newRateLimiter(requestsPerSecond, burst, time.Now)
+		return nil
+	}
+}
+
+//WithLenientLinks makes PostEvent send events with malformed links instead
+// of rejecting them, writing a warning to Trace
+func WithLenientLinks() Option {
+	return func(c *Client) error {
+		c.LenientLinks = true
+		return nil
+	}
+}
+
+//WithMaxMessageLength limits the messages of events and announcements to the
+// given number of characters. If truncate is true, longer messages are cut
+// short to fit; otherwise they give an error. See Client.MaxMessageLength.
+func WithMaxMessageLength(n int, truncate bool) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("Max message length must be positive")
+		}
+
+		c.MaxMessageLength = n
+		c.TruncateMessages = truncate
+		return nil
+	}
+}
+
+//WithTopicPattern requires the topics of events and announcements to match
+// the given pattern. See Client.TopicPattern.
+func WithTopicPattern(pattern *regexp.Regexp) Option {
+	return func(c *Client) error {
+		if pattern == nil {
+			return fmt.Errorf("Topic pattern cannot be nil")
+		}
+
+		c.TopicPattern = pattern
+		return nil
+	}
+}
+
+//WithSuccessStatus makes exactly the given status codes count as successes.
+// Responses with any other status give an APIError. See Client.IsSuccess.
+func WithSuccessStatus(codes ...int) Option {
+	return func(c *Client) error {
+		if len(codes) == 0 {
+			return fmt.Errorf("At least one success status code must be given")
+		}
+
+		success := make(map[int]bool, len(codes))
+		for _, code := range codes {
+			if code < 100 || code > 999 {
+				return fmt.Errorf("Invalid HTTP status code %d", code)
+			}
+
+			success[code] = true
+		}
+
+		c.IsSuccess = func(statusCode int) bool {
+			return success[statusCode]
+		}
+		return nil
+	}
+}
+
+//WithSuccessFunc sets the function that decides which status codes count as
+// successes. See Client.IsSuccess.
+func WithSuccessFunc(fn func(statusCode int) bool) Option {
+	return func(c *Client) error {
+		if fn == nil {
+			return fmt.Errorf("Success function cannot be nil")
+		}
+
+		c.IsSuccess = fn
+		return nil
+	}
+}
+
+//WithJSONDecoder sets the function used to decode the JSON bodies of
+// responses. See Client.DecodeJSON.
+func WithJSONDecoder(decode func(r io.Reader, v interface{}) error) Option {
+	return func(c *Client) error {
+		if decode == nil {
+			return fmt.Errorf("JSON decoder cannot be nil")
+		}
+
+		c.DecodeJSON = decode
+		return nil
+	}
+}
+
+//WithDryRun stops the client from sending anything to SHOUT!. See
+// Client.DryRun
+func WithDryRun() Option {
+	return func(c *Client) error {
+		c.DryRun = true
+		return nil
+	}
+}
+
+//WithWatchInterval sets how often Watch polls for changes
+func WithWatchInterval(interval time.Duration) Option {
+	return func(c *Client) error {
+		if interval <= 0 {
+			return fmt.Errorf("Watch interval must be positive")
+		}
+
+		c.WatchInterval = interval
+		return nil
+	}
+}
+
+//WithReconnectBackoff sets the delay before Watch and WatchStream first try
+// again after a failure, and the longest delay they will wait between tries
+func WithReconnectBackoff(base, max time.Duration) Option {
+	return func(c *Client) error {
+		if base <= 0 || max <= 0 {
+			return fmt.Errorf("Reconnect backoff durations must be positive")
+		}
+
+		if base > max {
+			return fmt.Errorf("Base reconnect backoff cannot be greater than max reconnect backoff")
+		}
+
+		c.ReconnectBackoff = base
+		c.MaxReconnectBackoff = max
+		return nil
+	}
+}
+
+//WithTopicCache makes GetTopic remember the states that it fetches for the
+// given TTL, returning a remembered state instead of fetching the topic again
+// while it is fresh. States returned by PostEvent are remembered too, and
+// anything that changes a topic through this client forgets its state. The
+// cache is kept per Client, and is safe for concurrent use. Use
+// InvalidateTopic to forget a topic's state early. If SHOUT! gives ETags,
+// they are kept in the cache, and used to fetch topics and lists of topics
+// conditionally once the TTL has passed; see GetTopicIfModified. Watch always
+// asks SHOUT! for the state, ignoring the TTL. A TTL of zero disables the
+// cache, such as for a clone made with Client.With.
+func WithTopicCache(ttl time.Duration) Option {
+	return func(c *Client) error {
+		if ttl < 0 {
+			return fmt.Errorf("Topic cache TTL cannot be negative")
+		}
+
+		if ttl == 0 {
+			c.topics = nil
+			return nil
+		}
+
+		c.topics = newTopicCache(ttl)
+		return nil
+	}
+}
+
+//WithSuppressUnchanged makes PostEvent skip sending an event when the last
+// event it sent for the same topic had the same OK value and severity,
+// returning the state from that last post instead. A change of severity, such
+// as an escalation to critical, is always sent. If maxAge is positive, an event
+// is sent anyway once maxAge has passed since the last one that was sent for
+// the topic. Acknowledging, resolving, silencing, unsilencing, or deleting a
+// topic through this client, or updating one of its events, forgets the last
+// event posted to it, so that the next one is sent. The record of past posts is
+// kept per Client, and is safe for concurrent use.
+func WithSuppressUnchanged(maxAge time.Duration) Option {
+	return func(c *Client) error {
+		if maxAge < 0 {
+			return fmt.Errorf("Suppression max age cannot be negative")
+		}
+
+		c.suppressor = newPostCache(maxAge)
+		return nil
+	}
+}
+
+//WithCoalescing makes concurrent calls to PostEvent with identical events
+// share a single request to SHOUT!, with every caller receiving the resulting
+// state. Events are identical if everything but their occurrence time and
+// idempotency key match. The request is bound to the context of whichever call
+// started it, so if that context is canceled, every call sharing the request
+// fails with the context's error. A call that is waiting on another's request
+// still returns as soon as its own context is done.
+func WithCoalescing() Option {
+	return func(c *Client) error {
+		c.flights = newFlightGroup()
+		return nil
+	}
+}
+
+//WithBasicAuth sets the credentials to send with every request using HTTP
+// basic auth. It cannot be used together with WithBearerToken.
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) error {
+		c.Username = username
+		c.Password = password
+		return nil
+	}
+}
+
+//WithBearerToken sets a token to send as a bearer token in the Authorization
+// header of every request. It cannot be used together with WithBasicAuth.
+func WithBearerToken(token string) Option {
+	return func(c *Client) error {
+		c.Token = token
+		return nil
+	}
+}
+
+//WithHeader adds a header that will be sent with every request. It may be
+// given multiple times, including with the same key to send multiple values
+func WithHeader(key, value string) Option {
+	return func(c *Client) error {
+		if c.Headers == nil {
+			c.Headers = http.Header{}
+		}
+
+		c.Headers.Add(key, value)
+		return nil
+	}
+}
+
+//WithRoundTripper wraps the transport used to send requests, for example to
+// log or trace them. It may be given multiple times, and the wrappers are
+// applied in the order given, so that the first one given sees each request
+// first. If an HTTP client is given with WithHTTPClient, its transport is
+// wrapped without modifying the given client.
+func WithRoundTripper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(c *Client) error {
+		if wrap == nil {
+			return fmt.Errorf("RoundTripper wrapper cannot be nil")
+		}
+
+		c.middleware = append(c.middleware, wrap)
+		return nil
+	}
+}
+
+//WithBaseContext sets the context used for requests made by methods that
+// don't take a context
+func WithBaseContext(ctx context.Context) Option {
+	return func(c *Client) error {
+		if ctx == nil {
+			return fmt.Errorf("Base context cannot be nil")
+		}
+
+		c.BaseContext = ctx
+		return nil
+	}
+}
+
+//WithCapture gives fn the full content of every request and response, for
+// debugging. See Client.Capture.
+func WithCapture(fn func(Capture)) Option {
+	return func(c *Client) error {
+		if fn == nil {
+			return fmt.Errorf("Capture function cannot be nil")
+		}
+
+		c.Capture = fn
+		return nil
+	}
+}
+
+//WithLogger sets a Logger that will be told about every attempt at a request
+func WithLogger(logger Logger) Option {
+	return func(c *Client) error {
+		c.Logger = logger
+		return nil
+	}
+}
+
+//WithMetrics sets a Metrics that will receive measurements of every request
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Client) error {
+		c.Metrics = metrics
+		return nil
+	}
+}
+
+//WithPropagator sets a Propagator that can add tracing headers to every
+// request from its context
+func WithPropagator(propagator Propagator) Option {
+	return func(c *Client) error {
+		c.Propagator = propagator
+		return nil
+	}
+}
+
+//WithRequestID sets a function that gives the request ID to send in the
+// X-Request-Id header of a request, typically read from its context. No
+// header is sent when it returns an empty string.
+func WithRequestID(requestID func(ctx context.Context) string) Option {
+	return func(c *Client) error {
+		c.RequestID = requestID
+		return nil
+	}
+}
+
+//WithClock sets the function used to get the current time, for example when
+// filling in a missing occurrence time on an event. This is mostly useful for
+// making tests deterministic.
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) error {
+		if now == nil {
+			return fmt.Errorf("Clock cannot be nil")
+		}
+
+		c.clock = now
+		return nil
+	}
+}
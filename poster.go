@@ -0,0 +1,15 @@
+package shout
+
+import "context"
+
+//Poster is the set of methods used to send events and announcements to
+// SHOUT!. *Client satisfies it, and code that only posts can depend on Poster
+// instead so that a fake can be used in tests.
+type Poster interface {
+	PostEvent(e EventIn, opts ...CallOption) (*StateOut, error)
+	PostEventCtx(ctx context.Context, e EventIn, opts ...CallOption) (*StateOut, error)
+	PostAnnouncement(announcement AnnouncementIn) error
+	PostAnnouncementCtx(ctx context.Context, announcement AnnouncementIn) error
+}
+
+var _ Poster = (*Client)(nil)
@@ -0,0 +1,68 @@
+package shout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//rateLimiter is a token bucket that allows bursts of up to burst requests,
+// refilling at rate tokens per second
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now(),
+		now:    now,
+	}
+}
+
+//Wait blocks until a request is allowed to be sent, or the context is done
+func (r *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := r.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+//reserve takes a token if one is available and returns zero. Otherwise, it
+// returns how long it will be until one is.
+func (r *rateLimiter) reserve() time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+}
@@ -0,0 +1,77 @@
+package shout
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//RateLimitInfo is the rate limit status that SHOUT! reported on a response,
+// from its X-RateLimit-Limit, X-RateLimit-Remaining, and X-RateLimit-Reset
+// headers
+type RateLimitInfo struct {
+	//Limit is the number of requests allowed in the current window
+	Limit int
+	//Remaining is the number of requests left in the current window
+	Remaining int
+	//Reset is when the current window ends. It is zero if SHOUT! did not say
+	Reset time.Time
+}
+
+//ParseRateLimit reads RateLimitInfo from the headers of a response. The reset
+// time may be given either as seconds since the epoch or as seconds from now.
+// It returns false if the response has no rate limit headers.
+func ParseRateLimit(header http.Header, now time.Time) (RateLimitInfo, bool) {
+	limit, limitErr := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if limitErr != nil && remainingErr != nil {
+		return RateLimitInfo{}, false
+	}
+
+	info := RateLimitInfo{Limit: limit, Remaining: remaining}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil && reset > 0 {
+		//No window is anywhere near this long, so anything larger must be a
+		// point in time rather than a delay
+		if reset > 365*24*60*60 {
+			info.Reset = time.Unix(reset, 0)
+		} else {
+			info.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	return info, true
+}
+
+//rateLimitTracker remembers the most recent RateLimitInfo seen
+type rateLimitTracker struct {
+	lock  sync.Mutex
+	info  RateLimitInfo
+	found bool
+}
+
+func (r *rateLimitTracker) update(header http.Header, now time.Time) {
+	info, found := ParseRateLimit(header, now)
+	if !found {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.info = info
+	r.found = true
+}
+
+//LastRateLimit returns the rate limit status from the most recent response
+// from SHOUT! that included one. It returns false if no response has. Rate
+// limits are only tracked for clients created with NewClient, and are shared
+// with clones of the client.
+func (c *Client) LastRateLimit() (RateLimitInfo, bool) {
+	if c.rateLimits == nil {
+		return RateLimitInfo{}, false
+	}
+
+	c.rateLimits.lock.Lock()
+	defer c.rateLimits.lock.Unlock()
+	return c.rateLimits.info, c.rateLimits.found
+}
@@ -0,0 +1,90 @@
+package shout
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultReconnectBackoff    = time.Second
+	defaultMaxReconnectBackoff = time.Minute
+)
+
+//ReconnectError is sent on the error channel of Watch and WatchStream when
+// they fail in a way that may not last. They keep going after sending it,
+// trying again after Delay.
+type ReconnectError struct {
+	//Err is the reason for the failure
+	Err error
+	//Failures is the number of times in a row that it has failed
+	Failures int
+	//Delay is how long it will wait before trying again
+	Delay time.Duration
+}
+
+func (e *ReconnectError) Error() string {
+	return fmt.Sprintf("%s (failure %d; trying again in %s)", e.Err, e.Failures, e.Delay)
+}
+
+func (e *ReconnectError) Unwrap() error {
+	return e.Err
+}
+
+//reconnectDelay returns a jittered delay before trying a watch again after
+// the given number of failures in a row
+func (c *Client) reconnectDelay(failures int) time.Duration {
+	base := c.ReconnectBackoff
+	if base <= 0 {
+		base = defaultReconnectBackoff
+	}
+
+	max := c.MaxReconnectBackoff
+	if max <= 0 {
+		max = defaultMaxReconnectBackoff
+	}
+
+	return jitteredBackoff(base, max, failures)
+}
+
+//isFatalWatchError returns true if an error from a watch means that trying
+// again will not help. That is the case for 4xx responses, other than those
+// that ask the client to slow down or try again, including the 404 that topic
+// methods give as ErrTopicNotFound. It is also the case for ErrDryRun, since a
+// dry run never contacts SHOUT!.
+func isFatalWatchError(err error) bool {
+	if errors.Is(err, ErrTopicNotFound) || errors.Is(err, ErrDryRun) {
+		return true
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !IsClientError(err) {
+		return false
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+
+	return true
+}
+
+//sendWatchError sends err on the error channel of a watch without blocking.
+// Transient errors are dropped if the channel is full, while a fatal error
+// takes the place of any error still waiting in it, so that it is always
+// seen. The watch must be the only sender on the channel.
+func sendWatchError(errs chan error, err error, fatal bool) {
+	if fatal {
+		select {
+		case <-errs:
+		default:
+		}
+	}
+
+	select {
+	case errs <- err:
+	default:
+	}
+}
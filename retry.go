@@ -0,0 +1,149 @@
+package shout
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 250 * time.Millisecond
+	defaultMaxRetryBackoff = 10 * time.Second
+	defaultMaxRetryAfter   = time.Minute
+)
+
+//transportError wraps an error from the HTTP client itself, meaning that the
+// request didn't get to SHOUT!, or no response came back. These are the only
+// errors without a response that are worth trying again, or that say anything
+// about the health of SHOUT!; an error building the request or reading the
+// response would only happen again.
+type transportError struct {
+	err error
+}
+
+func (t *transportError) Error() string {
+	return t.err.Error()
+}
+
+func (t *transportError) Unwrap() error {
+	return t.err
+}
+
+//isTransportError returns true if the error is a failure to get the request to
+// SHOUT!, or to get a response back
+func isTransportError(err error) bool {
+	var transportErr *transportError
+	return errors.As(err, &transportErr)
+}
+
+//isRetryable returns true if the error from a single attempt at a request is
+// one that might not happen again
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return isTransportError(err)
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+//waitToRetry sleeps for the backoff appropriate after the given number of
+// attempts, returning the context's error early if it is done first. If the
+// server asked for a specific delay with Retry-After, that is waited instead.
+func (c *Client) waitToRetry(ctx context.Context, attempts int, cause error) error {
+	delay := c.backoff(attempts)
+
+	var apiErr *APIError
+	if errors.As(cause, &apiErr) && apiErr.RetryAfter > 0 {
+		delay = apiErr.RetryAfter
+		max := c.MaxRetryAfter
+		if max <= 0 {
+			max = defaultMaxRetryAfter
+		}
+
+		if delay > max {
+			delay = max
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+//backoff returns a jittered delay that doubles with each attempt
+func (c *Client) backoff(attempts int) time.Duration {
+	base := c.RetryBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+
+	max := c.MaxRetryBackoff
+	if max <= 0 {
+		max = defaultMaxRetryBackoff
+	}
+
+	return jitteredBackoff(base, max, attempts)
+}
+
+//jitteredBackoff returns the delay before the given attempt, which starts at
+// base and doubles with each attempt, up to max
+func jitteredBackoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	//Wait somewhere between half and all of the delay, so that many clients
+	// failing at once don't all retry at once
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+//parseRetryAfter returns the delay requested by a Retry-After header, which
+// may be given either in seconds or as an HTTP date. Zero is returned if the
+// header is missing or malformed.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseInt(header, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(header); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
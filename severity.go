@@ -0,0 +1,45 @@
+package shout
+
+import "fmt"
+
+//Severity grades how bad a broken event is, so that notification rules can
+// treat warnings differently from critical failures
+type Severity int
+
+const (
+	//SeverityNone means that no severity was given
+	SeverityNone Severity = iota
+	//SeverityWarning marks a problem that needs attention, but not urgently
+	SeverityWarning
+	//SeverityCritical marks a problem that needs attention now
+	SeverityCritical
+)
+
+//String returns the name of the severity as sent to SHOUT!, or "unknown(<n>)"
+// if it is not one that this package knows about. SeverityNone gives an empty
+// string.
+func (s Severity) String() string {
+	switch s {
+	case SeverityNone:
+		return ""
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+//parseSeverity returns the severity with the given name. Names it doesn't
+// recognize give SeverityNone.
+func parseSeverity(s string) Severity {
+	switch s {
+	case "warning":
+		return SeverityWarning
+	case "critical":
+		return SeverityCritical
+	}
+
+	return SeverityNone
+}
@@ -0,0 +1,184 @@
+//Package shouttest provides helpers for testing code that uses go-shout
+package shouttest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	shout "github.com/thomasmitchell/go-shout"
+)
+
+//Fake is an in-memory shout.Poster that records everything posted to it. By
+// default, PostEvent returns a state derived from the events posted so far,
+// but a canned state or error can be set to be returned instead. The zero
+// value is ready to use, and it is safe for concurrent use.
+type Fake struct {
+	lock          sync.Mutex
+	events        []shout.EventIn
+	announcements []shout.AnnouncementIn
+	states        map[string]shout.StateOut
+	nextStates    []*shout.StateOut
+	nextErrs      []error
+}
+
+var _ shout.Poster = (*Fake)(nil)
+
+//PostEvent records the event and returns the next canned state or error, if
+// any were set, or otherwise the state the topic would have in SHOUT!
+func (f *Fake) PostEvent(e shout.EventIn, opts ...shout.CallOption) (*shout.StateOut, error) {
+	return f.PostEventCtx(context.Background(), e, opts...)
+}
+
+//PostEventCtx is PostEvent. If the context is already done, nothing is
+// recorded and the context's error is returned. Call options are accepted so
+// that Fake satisfies shout.Poster, but have no effect.
+func (f *Fake) PostEventCtx(ctx context.Context, e shout.EventIn, opts ...shout.CallOption) (*shout.StateOut, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.events = append(f.events, e)
+	if len(f.nextErrs) > 0 {
+		err := f.nextErrs[0]
+		f.nextErrs = f.nextErrs[1:]
+		return nil, err
+	}
+
+	state := f.advance(e)
+
+	if len(f.nextStates) > 0 {
+		state = *f.nextStates[0]
+		f.nextStates = f.nextStates[1:]
+	}
+
+	return &state, nil
+}
+
+//advance updates the tracked state of the event's topic and returns it
+func (f *Fake) advance(e shout.EventIn) shout.StateOut {
+	if f.states == nil {
+		f.states = map[string]shout.StateOut{}
+	}
+
+	occurredAt := e.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	event := shout.EventOut{
+		OccurredAt: occurredAt,
+		ReportedAt: time.Now(),
+		OK:         e.OK,
+		Message:    e.Message,
+		Link:       e.Link,
+		Severity:   e.Severity,
+		Metadata:   e.Metadata,
+	}
+
+	prev, found := f.states[e.Topic]
+	if e.Informational {
+		//Informational events don't affect the state
+		if !found {
+			prev = shout.StateOut{Name: e.Topic, State: shout.TopicWorking}
+		}
+
+		return prev
+	}
+
+	next := shout.StateOut{
+		Name:     e.Topic,
+		Previous: prev.Last,
+		First:    prev.First,
+		Last:     event,
+	}
+
+	switch {
+	case !e.OK:
+		next.State = shout.TopicBroken
+	case found && !prev.Last.OK:
+		next.State = shout.TopicFixed
+	default:
+		next.State = shout.TopicWorking
+	}
+
+	if !found || prev.Last.OK != e.OK {
+		next.First = event
+	}
+
+	f.states[e.Topic] = next
+	return next
+}
+
+//PostAnnouncement records the announcement, and returns the next canned error
+// if one was set
+func (f *Fake) PostAnnouncement(announcement shout.AnnouncementIn) error {
+	return f.PostAnnouncementCtx(context.Background(), announcement)
+}
+
+//PostAnnouncementCtx is PostAnnouncement. If the context is already done,
+// nothing is recorded and the context's error is returned.
+func (f *Fake) PostAnnouncementCtx(ctx context.Context, announcement shout.AnnouncementIn) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.announcements = append(f.announcements, announcement)
+	if len(f.nextErrs) > 0 {
+		err := f.nextErrs[0]
+		f.nextErrs = f.nextErrs[1:]
+		return err
+	}
+
+	return nil
+}
+
+//SetNextState queues a state to be returned by a future call to PostEvent,
+// in place of the derived one. States are returned in the order queued.
+func (f *Fake) SetNextState(state shout.StateOut) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.nextStates = append(f.nextStates, &state)
+}
+
+//SetNextError queues an error to be returned by a future call to PostEvent or
+// PostAnnouncement. Errors are returned in the order queued, and take
+// precedence over queued states.
+func (f *Fake) SetNextError(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.nextErrs = append(f.nextErrs, err)
+}
+
+//Events returns every event posted so far, in the order they were posted
+func (f *Fake) Events() []shout.EventIn {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]shout.EventIn(nil), f.events...)
+}
+
+//Announcements returns every announcement posted so far, in the order they
+// were posted
+func (f *Fake) Announcements() []shout.AnnouncementIn {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]shout.AnnouncementIn(nil), f.announcements...)
+}
+
+//Reset forgets everything that has been posted, and any queued states and
+// errors
+func (f *Fake) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.events = nil
+	f.announcements = nil
+	f.states = nil
+	f.nextStates = nil
+	f.nextErrs = nil
+}
@@ -0,0 +1,229 @@
+package shouttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	shout "github.com/thomasmitchell/go-shout"
+)
+
+//Server is an httptest.Server that speaks the SHOUT! /events API. Events
+// posted to it are answered with the state the topic would have in SHOUT!,
+// unless a canned response has been queued. Every request it receives is
+// recorded for inspection.
+type Server struct {
+	*httptest.Server
+
+	fake      Fake
+	lock      sync.Mutex
+	requests  []Request
+	responses []response
+}
+
+//Request is a request received by a Server
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+//Decode unmarshals the JSON body of the request into v
+func (r Request) Decode(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+//Event decodes the body of the request as an event posted by
+// shout.Client.PostEvent
+func (r Request) Event() (shout.EventIn, error) {
+	raw := wireEvent{}
+	err := r.Decode(&raw)
+	if err != nil {
+		return shout.EventIn{}, err
+	}
+
+	return raw.eventIn(), nil
+}
+
+type response struct {
+	status int
+	body   []byte
+}
+
+type wireEvent struct {
+	Topic      string            `json:"topic"`
+	Message    string            `json:"message"`
+	Link       string            `json:"link"`
+	OccurredAt int64             `json:"occurred-at"`
+	OK         *bool             `json:"ok"`
+	Severity   string            `json:"severity"`
+	Metadata   map[string]string `json:"metadata"`
+}
+
+func (w wireEvent) eventIn() shout.EventIn {
+	ret := shout.EventIn{
+		Topic:    w.Topic,
+		Message:  w.Message,
+		Link:     w.Link,
+		Metadata: w.Metadata,
+	}
+
+	if w.OccurredAt != 0 {
+		ret.OccurredAt = time.Unix(w.OccurredAt, 0)
+	}
+
+	if w.OK != nil {
+		ret.OK = *w.OK
+	} else {
+		ret.Informational = true
+	}
+
+	switch w.Severity {
+	case shout.SeverityWarning.String():
+		ret.Severity = shout.SeverityWarning
+	case shout.SeverityCritical.String():
+		ret.Severity = shout.SeverityCritical
+	}
+
+	return ret
+}
+
+//NewServer starts and returns a Server. The caller should call Close when
+// finished with it.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+//Client returns a shout.Client that sends its requests to the server
+func (s *Server) Client() *shout.Client {
+	return &shout.Client{Target: s.URL, HTTPClient: s.Server.Client()}
+}
+
+//Respond queues a response to be sent, in order, in place of the normal
+// handling of a request. This can be used to simulate error statuses and
+// malformed bodies.
+func (s *Server) Respond(status int, body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = append(s.responses, response{status: status, body: []byte(body)})
+}
+
+//RespondState queues a response with the given topic state in SHOUT!'s wire
+// format
+func (s *Server) RespondState(state shout.StateOut) {
+	s.Respond(http.StatusOK, string(encodeState(state)))
+}
+
+//Requests returns every request that the server has received, in the order
+// they were received
+func (s *Server) Requests() []Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	s.lock.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+
+	var canned *response
+	if len(s.responses) > 0 {
+		canned = &s.responses[0]
+		s.responses = s.responses[1:]
+	}
+	s.lock.Unlock()
+
+	if canned != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(canned.status)
+		w.Write(canned.body)
+		return
+	}
+
+	if r.URL.Path != "/events" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	raw := wireEvent{}
+	err := json.NewDecoder(bytes.NewReader(body)).Decode(&raw)
+	if err != nil || raw.Topic == "" {
+		http.Error(w, `{"error":"malformed event"}`, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	//Announcements and informational events carry no ok field, and don't
+	// change any state
+	state, _ := s.fake.PostEvent(raw.eventIn())
+	w.Write(encodeState(*state))
+}
+
+func encodeState(state shout.StateOut) []byte {
+	type eventRaw struct {
+		OccurredAt int64             `json:"occurred-at"`
+		ReportedAt int64             `json:"reported-at"`
+		OK         bool              `json:"ok"`
+		Message    string            `json:"message"`
+		Link       string            `json:"link"`
+		Severity   string            `json:"severity,omitempty"`
+		Metadata   map[string]string `json:"metadata,omitempty"`
+	}
+
+	toRaw := func(e shout.EventOut) eventRaw {
+		return eventRaw{
+			OccurredAt: unix(e.OccurredAt),
+			ReportedAt: unix(e.ReportedAt),
+			OK:         e.OK,
+			Message:    e.Message,
+			Link:       e.Link,
+			Severity:   e.Severity.String(),
+			Metadata:   e.Metadata,
+		}
+	}
+
+	b, _ := json.Marshal(struct {
+		Name     string   `json:"name"`
+		State    string   `json:"state"`
+		Previous eventRaw `json:"previous"`
+		First    eventRaw `json:"first"`
+		Last     eventRaw `json:"last"`
+	}{
+		Name:     state.Name,
+		State:    state.State.String(),
+		Previous: toRaw(state.Previous),
+		First:    toRaw(state.First),
+		Last:     toRaw(state.Last),
+	})
+
+	return b
+}
+
+//unix returns the epoch time of t, or 0 for the zero time
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
@@ -0,0 +1,304 @@
+package shout
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//WatchStream subscribes to the stream of topic states that SHOUT! sends as
+// server-sent events, sending each state on the returned state channel as it
+// arrives. This is the push-based alternative to Watch, which polls a single
+// topic.
+//
+//If the connection fails or drops, a *ReconnectError is sent on the error
+// channel, and the client reconnects after ReconnectBackoff, or the delay that
+// SHOUT! asked for if that is longer, asking SHOUT! to resume from the last
+// event received. The delay grows for each failure in a row, and starts over
+// once an event is received. The error channel holds one error; if an error is
+// not received before the next one occurs, the later error is dropped. If
+// SHOUT! responds with a 4xx status that won't change by trying again, that
+// error is sent instead and the stream stops. Both channels are closed once
+// the stream stops or the context is done.
+//
+//The stream is not subject to Timeout, MaxRetries, or MaxResponseSize, since
+// it is expected to stay open indefinitely. Opening it goes through the rate
+// limiter, circuit breaker, and failover targets like any other request; while
+// the circuit is open, ErrCircuitOpen is sent as a *ReconnectError. If DryRun
+// is set, ErrDryRun is sent and the stream stops without connecting.
+func (c *Client) WatchStream(ctx context.Context) (<-chan StateOut, <-chan error) {
+	states := make(chan StateOut)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(states)
+		defer close(errs)
+
+		if c.DryRun {
+			sendWatchError(errs, ErrDryRun, true)
+			return
+		}
+
+		s := &eventStream{client: c, states: states}
+		failures := 0
+		for {
+			received, err := s.connect(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if received {
+				failures = 0
+			}
+			failures++
+
+			if err == nil {
+				err = fmt.Errorf("Event stream was closed by SHOUT!")
+			} else if isFatalWatchError(err) {
+				sendWatchError(errs, err, true)
+				return
+			}
+
+			delay := c.reconnectDelay(failures)
+			if s.retry > delay {
+				delay = s.retry
+			}
+
+			sendWatchError(errs, &ReconnectError{Err: err, Failures: failures, Delay: delay}, false)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return states, errs
+}
+
+//eventStream holds what is kept between connections to the event stream
+type eventStream struct {
+	client *Client
+	states chan<- StateOut
+	//lastID is the ID of the last event received, sent as Last-Event-ID when
+	// reconnecting
+	lastID string
+	//retry is the reconnection delay last asked for by SHOUT!
+	retry time.Duration
+}
+
+//connect opens the event stream and reads states from it until it ends. It
+// returns true if any state was received.
+func (s *eventStream) connect(ctx context.Context) (bool, error) {
+	resp, err := s.open(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	err = decompressResponse(resp)
+	if err != nil {
+		return false, fmt.Errorf("Could not decompress event stream: %s", err)
+	}
+
+	return s.read(ctx, resp.Body)
+}
+
+//open connects to the event stream, going through the circuit breaker and
+// failover targets as any other request does. Only the opening of the stream
+// counts towards their health, not how long it stays open.
+func (s *eventStream) open(ctx context.Context) (*http.Response, error) {
+	c := s.client
+	if c.breaker != nil && !c.breaker.allow(c.now()) {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := s.openFailover(ctx)
+	if c.breaker != nil {
+		if err != nil && ctx.Err() != nil {
+			c.breaker.abandon()
+		} else {
+			c.breaker.record(err != nil && shouldFailover(err), c.now())
+		}
+	}
+
+	return resp, err
+}
+
+//openFailover is doFailover for the event stream
+func (s *eventStream) openFailover(ctx context.Context) (*http.Response, error) {
+	c := s.client
+	if c.failover == nil {
+		return s.openTarget(ctx, c.Target)
+	}
+
+	var err error
+	for _, i := range c.failover.order(c.now()) {
+		var resp *http.Response
+		resp, err = s.openTarget(ctx, c.failover.targets[i])
+		if ctx.Err() != nil {
+			return resp, err
+		}
+
+		if err == nil || !shouldFailover(err) {
+			c.failover.succeeded(i)
+			return resp, err
+		}
+
+		c.failover.failed(i, c.now())
+	}
+
+	return nil, err
+}
+
+//openTarget connects to the event stream of the given target. The body of the
+// response is left open for reading if it has a success status.
+func (s *eventStream) openTarget(ctx context.Context, target string) (*http.Response, error) {
+	c := s.client
+	if c.limiter != nil {
+		err := c.limiter.Wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	if s.lastID != "" {
+		header.Set("Last-Event-ID", s.lastID)
+	}
+
+	req, err := c.newRequest(ctx, target, "GET", "/events/stream", header, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//Copy the client so that its timeout doesn't cut the stream short
+	client := *c.httpClient()
+	client.Timeout = 0
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &transportError{err: err}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       b,
+			Message:    parseErrorMessage(b),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), c.now()),
+		}
+	}
+
+	return resp, nil
+}
+
+//read parses server-sent events from r, sending each state on the state
+// channel, until r ends or the context is done
+func (s *eventStream) read(ctx context.Context, r io.Reader) (bool, error) {
+	received := false
+	reader := bufio.NewReader(r)
+	var (
+		event string
+		data  bytes.Buffer
+	)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return received, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			//A blank line ends the event
+			if data.Len() > 0 && (event == "" || event == "state") {
+				state, err := s.parse(data.Bytes())
+				if err != nil {
+					return received, err
+				}
+
+				select {
+				case s.states <- *state:
+					received = true
+				case <-ctx.Done():
+					return received, ctx.Err()
+				}
+			}
+
+			event = ""
+			data.Reset()
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			//Comments keep the connection alive, and are otherwise ignored
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+//parse decodes the data of one event from the stream as a topic state
+func (s *eventStream) parse(data []byte) (*StateOut, error) {
+	c := s.client
+	decode := c.DecodeJSON
+	if decode == nil {
+		decode = decodeJSON
+	}
+
+	raw := stateRaw{}
+	err := decode(bytes.NewReader(data), &raw)
+	if err != nil {
+		return nil, &InvalidResponseError{
+			StatusCode:  http.StatusOK,
+			ContentType: "text/event-stream",
+			Body:        append([]byte(nil), data...),
+			Err:         err,
+		}
+	}
+
+	return parseStateRaw(raw, c.MillisecondTimestamps)
+}
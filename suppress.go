@@ -0,0 +1,69 @@
+package shout
+
+import (
+	"sync"
+	"time"
+)
+
+//postCache remembers the last event posted to each topic, so that posts that
+// wouldn't change anything can be skipped
+type postCache struct {
+	maxAge time.Duration
+
+	lock    sync.Mutex
+	entries map[string]postCacheEntry
+}
+
+type postCacheEntry struct {
+	ok       bool
+	severity Severity
+	state    StateOut
+	postedAt time.Time
+}
+
+func newPostCache(maxAge time.Duration) *postCache {
+	return &postCache{
+		maxAge:  maxAge,
+		entries: map[string]postCacheEntry{},
+	}
+}
+
+//get returns the state from the last post to the topic, if that post had the
+// same OK value and severity and was recent enough
+func (p *postCache) get(topic string, ok bool, severity Severity, now time.Time) (*StateOut, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entry, found := p.entries[topic]
+	if !found || entry.ok != ok || entry.severity != severity {
+		return nil, false
+	}
+
+	if p.maxAge > 0 && now.Sub(entry.postedAt) >= p.maxAge {
+		return nil, false
+	}
+
+	state := entry.state
+	return &state, true
+}
+
+func (p *postCache) put(topic string, ok bool, severity Severity, state StateOut, now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.entries[topic] = postCacheEntry{
+		ok:       ok,
+		severity: severity,
+		state:    state,
+		postedAt: now,
+	}
+}
+
+//forget drops the last post to the topic, so that the next post to it is sent
+// no matter what it says
+func (p *postCache) forget(topic string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.entries, topic)
+}
@@ -0,0 +1,56 @@
+package shout_test
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	shout "github.com/thomasmitchell/go-shout"
+)
+
+func TestSuppressAfterTopicChange(t *testing.T) {
+	var posts int64
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case r.URL.Path == "/events":
+			atomic.AddInt64(&posts, 1)
+		}
+
+		respondWith(brokenStateJSON)(w, r)
+	}
+
+	client, server := newTestClient(t, handler, shout.WithSuppressUnchanged(0))
+	defer server.Close()
+
+	broken := shout.EventIn{Topic: "my-topic", Message: "disk full"}
+	post := func(expected int64) {
+		t.Helper()
+		_, err := client.PostEvent(broken)
+		if err != nil {
+			t.Fatalf("PostEvent failed: %s", err)
+		}
+
+		if n := atomic.LoadInt64(&posts); n != expected {
+			t.Fatalf("Expected %d events to have been sent, got %d", expected, n)
+		}
+	}
+
+	post(1)
+	//A repeat is suppressed
+	post(1)
+
+	_, err := client.ResolveTopic("my-topic")
+	if err != nil {
+		t.Fatalf("ResolveTopic failed: %s", err)
+	}
+	post(2)
+
+	err = client.DeleteTopic("my-topic")
+	if err != nil {
+		t.Fatalf("DeleteTopic failed: %s", err)
+	}
+	post(3)
+}
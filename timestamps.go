@@ -0,0 +1,29 @@
+package shout
+
+import "time"
+
+//toEpoch returns t as seconds since the epoch, or as milliseconds if millis is
+// true
+func toEpoch(t time.Time, millis bool) int64 {
+	if millis {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+
+	return t.Unix()
+}
+
+//fromEpoch is the inverse of toEpoch, except that 0 gives the zero time, since
+// that is what SHOUT! sends for an event that doesn't exist, like the previous
+// event of a brand new topic. Times are returned in UTC, so that they compare
+// and format the same no matter the local time zone.
+func fromEpoch(n int64, millis bool) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	if millis {
+		return time.Unix(0, n*int64(time.Millisecond)).UTC()
+	}
+
+	return time.Unix(n, 0).UTC()
+}
@@ -0,0 +1,93 @@
+package shout_test
+
+import (
+	"testing"
+	"time"
+
+	shout "github.com/thomasmitchell/go-shout"
+)
+
+func TestEmptyEventsAreZero(t *testing.T) {
+	//A brand-new topic has no previous event, and its first event is its last
+	body := `{
+		"name": "new-topic",
+		"state": "broken",
+		"previous": {},
+		"first": {"occurred-at": 0, "reported-at": 0},
+		"last": {"occurred-at": 1500000200, "reported-at": 1500000201, "ok": false, "message": "disk full"}
+	}`
+
+	client, server := newTestClient(t, respondWith(body))
+	defer server.Close()
+
+	state, err := client.PostEvent(shout.EventIn{Topic: "new-topic", Message: "disk full"})
+	if err != nil {
+		t.Fatalf("PostEvent failed: %s", err)
+	}
+
+	events := map[string]shout.EventOut{"previous": state.Previous, "first": state.First}
+	for name, event := range events {
+		if !event.OccurredAt.IsZero() {
+			t.Errorf("Expected %s event to have a zero OccurredAt, got %s", name, event.OccurredAt)
+		}
+
+		if !event.ReportedAt.IsZero() {
+			t.Errorf("Expected %s event to have a zero ReportedAt, got %s", name, event.ReportedAt)
+		}
+	}
+
+	if state.Last.OccurredAt.IsZero() {
+		t.Errorf("Expected last event to have an OccurredAt")
+	}
+}
+
+func TestTimesAreUTC(t *testing.T) {
+	//Make sure that local time isn't UTC, so that it can't be mistaken for it
+	local := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*60*60)
+	defer func() { time.Local = local }()
+
+	millisBody := `{
+		"name": "my-topic",
+		"state": "broken",
+		"previous": {"occurred-at": 1500000000000, "reported-at": 1500000001000, "ok": true},
+		"first": {"occurred-at": 1500000100000, "reported-at": 1500000101000, "ok": false},
+		"last": {"occurred-at": 1500000200000, "reported-at": 1500000201000, "ok": false}
+	}`
+
+	tests := []struct {
+		name string
+		body string
+		opts []shout.Option
+	}{
+		{name: "seconds", body: brokenStateJSON},
+		{name: "milliseconds", body: millisBody, opts: []shout.Option{shout.WithMillisecondTimestamps()}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := newTestClient(t, respondWith(test.body), test.opts...)
+			defer server.Close()
+
+			state, err := client.PostEvent(shout.EventIn{Topic: "my-topic", Message: "disk full"})
+			if err != nil {
+				t.Fatalf("PostEvent failed: %s", err)
+			}
+
+			events := map[string]shout.EventOut{"previous": state.Previous, "first": state.First, "last": state.Last}
+			for name, event := range events {
+				if event.OccurredAt.Location() != time.UTC {
+					t.Errorf("Expected %s event OccurredAt in UTC, got %s", name, event.OccurredAt.Location())
+				}
+
+				if event.ReportedAt.Location() != time.UTC {
+					t.Errorf("Expected %s event ReportedAt in UTC, got %s", name, event.ReportedAt.Location())
+				}
+			}
+
+			if !state.Last.OccurredAt.Equal(time.Unix(1500000200, 0)) {
+				t.Errorf("Expected last event to have occurred at %s, got %s", time.Unix(1500000200, 0).UTC(), state.Last.OccurredAt)
+			}
+		})
+	}
+}
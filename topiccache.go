@@ -0,0 +1,188 @@
+package shout
+
+import (
+	"sync"
+	"time"
+)
+
+//topicCache remembers recently fetched topic states, so that GetTopic can skip
+// fetching a topic that was seen moments ago. Along with each state, it keeps
+// the ETag that SHOUT! gave for it, so that it can be revalidated once the TTL
+// has passed. It also keeps the last list of topics from ListTopics, which is
+// only used with its ETag.
+type topicCache struct {
+	ttl time.Duration
+
+	lock     sync.Mutex
+	entries  map[string]topicCacheEntry
+	list     []StateOut
+	listETag string
+	//gen counts invalidations, so that a fetch that was in flight while its
+	// topic changed doesn't store what it fetched
+	gen uint64
+}
+
+type topicCacheEntry struct {
+	state     StateOut
+	etag      string
+	fetchedAt time.Time
+}
+
+func newTopicCache(ttl time.Duration) *topicCache {
+	return &topicCache{
+		ttl:     ttl,
+		entries: map[string]topicCacheEntry{},
+	}
+}
+
+//get returns the cached state of the topic, if it was stored less than the TTL
+// ago
+func (t *topicCache) get(name string, now time.Time) (*StateOut, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	entry, found := t.entries[name]
+	if !found {
+		return nil, false
+	}
+
+	if now.Sub(entry.fetchedAt) >= t.ttl {
+		//Keep an expired entry only if it can be revalidated
+		if entry.etag == "" {
+			delete(t.entries, name)
+		}
+		return nil, false
+	}
+
+	state := entry.state
+	return &state, true
+}
+
+//revalidate returns the cached entry for the topic, regardless of its age, if
+// it has an ETag to revalidate it with
+func (t *topicCache) revalidate(name string) (*topicCacheEntry, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	entry, found := t.entries[name]
+	if !found || entry.etag == "" {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+//generation returns a value that changes whenever anything is invalidated. It
+// is read before a fetch, and given to putFetched after.
+func (t *topicCache) generation() uint64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.gen
+}
+
+//putFetched is put for a state fetched from SHOUT!, which is only stored if
+// nothing has been invalidated since the given generation, since the state may
+// be from before the change that caused the invalidation
+func (t *topicCache) putFetched(gen uint64, name string, state StateOut, etag string, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.gen != gen {
+		return
+	}
+
+	t.entries[name] = topicCacheEntry{
+		state:     state,
+		etag:      etag,
+		fetchedAt: now,
+	}
+}
+
+func (t *topicCache) put(name string, state StateOut, etag string, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.entries[name] = topicCacheEntry{
+		state:     state,
+		etag:      etag,
+		fetchedAt: now,
+	}
+}
+
+//lastList returns a copy of the last list of topics, if SHOUT! gave an ETag
+// for it
+func (t *topicCache) lastList() ([]StateOut, string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.listETag == "" {
+		return nil, "", false
+	}
+
+	return append([]StateOut{}, t.list...), t.listETag, true
+}
+
+//putList stores the list of topics fetched from SHOUT!, unless anything has
+// been invalidated since the given generation
+func (t *topicCache) putList(gen uint64, list []StateOut, etag string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.gen != gen {
+		return
+	}
+
+	if etag == "" {
+		t.list, t.listETag = nil, ""
+		return
+	}
+
+	t.list = append([]StateOut{}, list...)
+	t.listETag = etag
+}
+
+//invalidate drops the cached state of the topic, along with the last list of
+// topics, which may include it
+func (t *topicCache) invalidate(name string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.entries, name)
+	t.list, t.listETag = nil, ""
+	t.gen++
+}
+
+func (t *topicCache) clear() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.entries = map[string]topicCacheEntry{}
+	t.list, t.listETag = nil, ""
+	t.gen++
+}
+
+//InvalidateTopic drops the cached state of the topic with the given name, so
+// that the next GetTopic for it fetches it from SHOUT!. It does nothing if
+// WithTopicCache is not in use.
+func (c *Client) InvalidateTopic(name string) {
+	if c.topics != nil {
+		c.topics.invalidate(name)
+	}
+}
+
+//topicChanged forgets what is remembered about the topic with the given name
+// after a request that may have changed it other than by posting an event: its
+// cached state, and the last event posted to it for WithSuppressUnchanged, so
+// that the next event is sent even if it repeats that one
+func (c *Client) topicChanged(name string) {
+	c.InvalidateTopic(name)
+	if c.suppressor != nil {
+		c.suppressor.forget(name)
+	}
+}
+
+//InvalidateTopics drops every cached topic state. It does nothing if
+// WithTopicCache is not in use.
+func (c *Client) InvalidateTopics() {
+	if c.topics != nil {
+		c.topics.clear()
+	}
+}
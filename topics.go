@@ -0,0 +1,305 @@
+package shout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//GetTopic fetches the current state of the topic with the given name, without
+// changing it. If SHOUT! does not know of the topic, ErrTopicNotFound is
+// returned. If WithTopicCache is in use, a state fetched within the TTL is
+// returned without contacting SHOUT!.
+func (c *Client) GetTopic(name string) (*StateOut, error) {
+	return c.GetTopicCtx(c.context(), name)
+}
+
+//GetTopicCtx is GetTopic, but the request is bound to the given context
+func (c *Client) GetTopicCtx(ctx context.Context, name string) (*StateOut, error) {
+	state, _, err := c.GetTopicIfModifiedCtx(ctx, name)
+	return state, err
+}
+
+//GetTopicIfModified is GetTopic, but also reports whether the state is new to
+// this client. If WithTopicCache is in use, a state cached within the TTL is
+// returned as unmodified. Once the TTL has passed, the topic is fetched with
+// the ETag that SHOUT! last gave for it, if any, and if SHOUT! responds with
+// 304 Not Modified, the cached state is returned as unmodified and kept for
+// another TTL. Without the cache, the state is always reported as modified.
+func (c *Client) GetTopicIfModified(name string) (*StateOut, bool, error) {
+	return c.GetTopicIfModifiedCtx(c.context(), name)
+}
+
+//GetTopicIfModifiedCtx is GetTopicIfModified, but the request is bound to the
+// given context
+func (c *Client) GetTopicIfModifiedCtx(ctx context.Context, name string) (*StateOut, bool, error) {
+	if c.topics != nil {
+		if state, found := c.topics.get(name, c.now()); found {
+			return state, false, nil
+		}
+	}
+
+	return c.fetchTopic(ctx, name)
+}
+
+//fetchTopic fetches the topic from SHOUT!, ignoring the TTL of the cache. If
+// the cache has an ETag for the topic, the fetch is conditional on it, and a
+// 304 Not Modified gives the cached state. It returns true if the state was not
+// the cached one.
+func (c *Client) fetchTopic(ctx context.Context, name string) (*StateOut, bool, error) {
+	var (
+		header http.Header
+		cached *topicCacheEntry
+		gen    uint64
+	)
+	if c.topics != nil {
+		gen = c.topics.generation()
+		if entry, found := c.topics.revalidate(name); found {
+			cached = entry
+			header = http.Header{"If-None-Match": {entry.etag}}
+		}
+	}
+
+	resp, err := c.doRequest(ctx, "GET", topicPath(name), header, nil)
+	if cached != nil && isNotModified(resp, err) {
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+
+		c.topics.putFetched(gen, name, cached.state, cached.etag, c.now())
+		state := cached.state
+		return &state, false, nil
+	}
+
+	if err != nil {
+		return nil, false, topicError(err)
+	}
+
+	state, err := c.decodeState(resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if c.topics != nil {
+		c.topics.putFetched(gen, name, *state, resp.Header.Get("ETag"), c.now())
+	}
+
+	return state, true, nil
+}
+
+//isNotModified returns true if the request got a 304 Not Modified response,
+// which is given as an error if RejectRedirects is set
+func isNotModified(resp *http.Response, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusNotModified
+	}
+
+	return err == nil && resp.StatusCode == http.StatusNotModified
+}
+
+//ListTopics fetches the current state of every topic that SHOUT! knows about.
+// If there are no topics, an empty, non-nil slice is returned.
+func (c *Client) ListTopics() ([]StateOut, error) {
+	return c.ListTopicsCtx(c.context())
+}
+
+//ListTopicsCtx is ListTopics, but the request is bound to the given context.
+// If WithTopicCache is in use and SHOUT! gave an ETag for the last list, the
+// list is fetched conditionally, and the last list is returned again if SHOUT!
+// responds with 304 Not Modified.
+func (c *Client) ListTopicsCtx(ctx context.Context) ([]StateOut, error) {
+	var (
+		header   http.Header
+		cached   []StateOut
+		haveList bool
+		gen      uint64
+	)
+	if c.topics != nil {
+		gen = c.topics.generation()
+		var etag string
+		cached, etag, haveList = c.topics.lastList()
+		if haveList {
+			header = http.Header{"If-None-Match": {etag}}
+		}
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/topics", header, nil)
+	if haveList && isNotModified(resp, err) {
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+
+		return cached, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	raws := []stateRaw{}
+	err = c.decodeJSON(resp, &raws)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]StateOut, 0, len(raws))
+	for _, raw := range raws {
+		state, err := parseStateRaw(raw, c.MillisecondTimestamps)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, *state)
+	}
+
+	if c.topics != nil {
+		c.topics.putList(gen, ret, resp.Header.Get("ETag"))
+	}
+
+	return ret, nil
+}
+
+//DeleteTopic removes the topic with the given name, along with its state and
+// history. If SHOUT! does not know of the topic, ErrTopicNotFound is returned.
+func (c *Client) DeleteTopic(name string) error {
+	return c.DeleteTopicCtx(c.context(), name)
+}
+
+//DeleteTopicCtx is DeleteTopic, but the request is bound to the given context
+func (c *Client) DeleteTopicCtx(ctx context.Context, name string) error {
+	resp, err := c.doRequest(ctx, "DELETE", topicPath(name), nil, nil)
+	c.topicChanged(name)
+	if err != nil {
+		return topicError(err)
+	}
+
+	drainAndClose(resp.Body)
+	return nil
+}
+
+//AcknowledgeTopic marks the broken topic with the given name as acknowledged,
+// so that SHOUT! knows someone is looking into it. The note, if not empty, is
+// stored alongside the acknowledgement. The resulting state of the topic is
+// returned. If SHOUT! does not know of the topic, ErrTopicNotFound is returned.
+func (c *Client) AcknowledgeTopic(name, note string) (*StateOut, error) {
+	return c.AcknowledgeTopicCtx(c.context(), name, note)
+}
+
+//AcknowledgeTopicCtx is AcknowledgeTopic, but the request is bound to the
+// given context
+func (c *Client) AcknowledgeTopicCtx(ctx context.Context, name, note string) (*StateOut, error) {
+	jsonStruct := struct {
+		Note string `json:"note,omitempty"`
+	}{
+		Note: note,
+	}
+
+	jBytes, err := json.Marshal(&jsonStruct)
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal acknowledgement: %s", err)
+	}
+
+	return c.postTopicAction(ctx, name, "ack", jBytes)
+}
+
+//ResolveTopic manually marks the topic with the given name as fixed, without
+// waiting for a working event to be posted for it. The resulting state of the
+// topic is returned. If SHOUT! does not know of the topic, ErrTopicNotFound is
+// returned.
+func (c *Client) ResolveTopic(name string) (*StateOut, error) {
+	return c.ResolveTopicCtx(c.context(), name)
+}
+
+//ResolveTopicCtx is ResolveTopic, but the request is bound to the given
+// context
+func (c *Client) ResolveTopicCtx(ctx context.Context, name string) (*StateOut, error) {
+	return c.postTopicAction(ctx, name, "resolve", nil)
+}
+
+//SilenceTopic stops SHOUT! from sending notifications for the topic with the
+// given name until the given time, such as for the length of planned
+// maintenance. Events posted for the topic while it is silenced are still
+// recorded, and the topic is reported as TopicSilenced until the silence ends
+// or is lifted with Unsilence. If SHOUT! does not know of the topic,
+// ErrTopicNotFound is returned.
+func (c *Client) SilenceTopic(name string, until time.Time) error {
+	return c.SilenceTopicCtx(c.context(), name, until)
+}
+
+//SilenceTopicCtx is SilenceTopic, but the request is bound to the given
+// context
+func (c *Client) SilenceTopicCtx(ctx context.Context, name string, until time.Time) error {
+	if until.IsZero() {
+		return fmt.Errorf("Silence end time must be set")
+	}
+
+	jsonStruct := struct {
+		Until int64 `json:"until"`
+	}{
+		Until: toEpoch(until, c.MillisecondTimestamps),
+	}
+
+	jBytes, err := json.Marshal(&jsonStruct)
+	if err != nil {
+		return fmt.Errorf("Could not marshal silence: %s", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", topicPath(name)+"/silence", nil, jBytes)
+	c.topicChanged(name)
+	if err != nil {
+		return topicError(err)
+	}
+
+	drainAndClose(resp.Body)
+	return nil
+}
+
+//Unsilence lifts a silence placed on the topic with the given name by
+// SilenceTopic, so that notifications are sent for it again. If SHOUT! does
+// not know of the topic, ErrTopicNotFound is returned.
+func (c *Client) Unsilence(name string) error {
+	return c.UnsilenceCtx(c.context(), name)
+}
+
+//UnsilenceCtx is Unsilence, but the request is bound to the given context
+func (c *Client) UnsilenceCtx(ctx context.Context, name string) error {
+	resp, err := c.doRequest(ctx, "DELETE", topicPath(name)+"/silence", nil, nil)
+	c.topicChanged(name)
+	if err != nil {
+		return topicError(err)
+	}
+
+	drainAndClose(resp.Body)
+	return nil
+}
+
+//postTopicAction posts to an action endpoint beneath the topic with the given
+// name and decodes the topic state that comes back
+func (c *Client) postTopicAction(ctx context.Context, name, action string, body []byte) (*StateOut, error) {
+	resp, err := c.doRequest(ctx, "POST", topicPath(name)+"/"+action, nil, body)
+	c.topicChanged(name)
+	if err != nil {
+		return nil, topicError(err)
+	}
+
+	return c.decodeState(resp)
+}
+
+func topicPath(name string) string {
+	return "/topics/" + url.PathEscape(name)
+}
+
+//topicError translates a 404 from a topic resource into ErrTopicNotFound
+func topicError(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return ErrTopicNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,102 @@
+package shout
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	//defaultMaxIdleConns and defaultMaxIdleConnsPerHost are used by transports
+	// built by this package. A client typically talks to a single SHOUT! host,
+	// so the standard library's default of 2 idle connections per host causes
+	// needless connection churn under load.
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 32
+)
+
+var (
+	sharedTransportOnce sync.Once
+	sharedTransport     *http.Transport
+)
+
+//defaultTransport returns the transport used by clients that were given
+// neither an HTTP client nor any transport option: a copy of
+// http.DefaultTransport that allows more idle connections. It is built once
+// and shared by all such clients, and their clones, so that they share one
+// pool of connections as they would with http.DefaultTransport.
+func defaultTransport() *http.Transport {
+	sharedTransportOnce.Do(func() {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.MaxIdleConns = defaultMaxIdleConns
+		t.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+		sharedTransport = t
+	})
+
+	return sharedTransport
+}
+
+//modifyTransport replaces the client's transport with a modified copy of it,
+// starting from a copy of the default transport if there is none. The
+// transport is copied rather than changed in place because clones of the
+// client, or other clients, may be sharing it.
+func (c *Client) modifyTransport(modify func(*http.Transport)) {
+	var t *http.Transport
+	if c.transport != nil {
+		t = c.transport.Clone()
+	} else {
+		t = defaultTransport().Clone()
+	}
+
+	modify(t)
+	c.transport = t
+}
+
+//modifyTLSConfig replaces the client's transport with a copy whose TLS
+// configuration has been modified
+func (c *Client) modifyTLSConfig(modify func(*tls.Config)) {
+	c.modifyTransport(func(t *http.Transport) {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		} else {
+			t.TLSClientConfig = t.TLSClientConfig.Clone()
+		}
+
+		modify(t.TLSClientConfig)
+	})
+}
+
+//httpClientCache holds the HTTP client that a Client last built, along with
+// the settings it was built from, so that it is only built again if those
+// change
+type httpClientCache struct {
+	lock       sync.Mutex
+	built      *http.Client
+	httpClient *http.Client
+	timeout    time.Duration
+	transport  *http.Transport
+	middleware int
+}
+
+//get returns the HTTP client for c, building it if it hasn't been built for
+// c's current settings
+func (h *httpClientCache) get(c *Client) *http.Client {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.built != nil &&
+		h.httpClient == c.HTTPClient &&
+		h.timeout == c.Timeout &&
+		h.transport == c.transport &&
+		h.middleware == len(c.middleware) {
+		return h.built
+	}
+
+	h.built = c.buildHTTPClient()
+	h.httpClient = c.HTTPClient
+	h.timeout = c.Timeout
+	h.transport = c.transport
+	h.middleware = len(c.middleware)
+	return h.built
+}
@@ -0,0 +1,75 @@
+package shout
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+//Uptime returns the fraction of the window up to now that a topic was working,
+// given its events, such as from GetEvents. See UptimeBetween for how the
+// fraction is worked out.
+func Uptime(events []EventOut, window time.Duration) (float64, error) {
+	now := time.Now()
+	return UptimeBetween(events, now.Add(-window), now)
+}
+
+//UptimeBetween returns the fraction, from 0 to 1, of the time between from and
+// to that a topic was working, given its events. The events may be in any
+// order. It assumes that:
+//
+//   - The topic stays in the state of an event until the time of the next
+//     event, or until to if there is none.
+//   - Informational events say nothing about the state, and are ignored.
+//   - Before the first event, the state is unknown. The last event before
+//     from gives the state at from, but if there is none, the time between
+//     from and the first event is left out of the fraction.
+//
+//An error is returned if the range is empty, or if no event gives the state of
+// the topic at any point in it.
+func UptimeBetween(events []EventOut, from, to time.Time) (float64, error) {
+	if !to.After(from) {
+		return 0, fmt.Errorf("Uptime range must end after it starts")
+	}
+
+	sorted := make([]EventOut, 0, len(events))
+	for _, e := range events {
+		if !e.Informational {
+			sorted = append(sorted, e)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OccurredAt.Before(sorted[j].OccurredAt)
+	})
+
+	var known, up time.Duration
+	for i, e := range sorted {
+		start := e.OccurredAt
+		end := to
+		if i+1 < len(sorted) {
+			end = sorted[i+1].OccurredAt
+		}
+
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		known += end.Sub(start)
+		if e.OK {
+			up += end.Sub(start)
+		}
+	}
+
+	if known == 0 {
+		return 0, fmt.Errorf("No events give the state of the topic between %s and %s", formatTime(from), formatTime(to))
+	}
+
+	return float64(up) / float64(known), nil
+}
@@ -0,0 +1,8 @@
+package shout
+
+//Version is the version of this package. It is sent as part of the default
+// User-Agent header.
+const Version = "0.1.0"
+
+//DefaultUserAgent is the User-Agent header sent when Client.UserAgent is empty
+const DefaultUserAgent = "go-shout/" + Version
@@ -0,0 +1,88 @@
+package shout
+
+import (
+	"context"
+	"time"
+)
+
+//defaultWatchInterval is how often Watch polls if Client.WatchInterval is unset
+const defaultWatchInterval = 30 * time.Second
+
+//Watch polls the topic with the given name every WatchInterval, sending its
+// state on the returned state channel whenever it changes. The first state is
+// sent as soon as it is fetched.
+//
+//If polling fails, a *ReconnectError is sent on the error channel, and the
+// next poll is made after ReconnectBackoff in place of WatchInterval, with the
+// delay growing for each failure in a row. The error channel holds one error;
+// if an error is not received before the next one occurs, the later error is
+// dropped. If SHOUT! responds with a 4xx status that won't change by trying
+// again, that error is sent instead and watching stops; this includes
+// ErrTopicNotFound if SHOUT! does not know of the topic. If DryRun is set,
+// ErrDryRun is sent and watching stops. Both channels are closed once watching
+// stops or the context is done.
+func (c *Client) Watch(ctx context.Context, topic string) (<-chan StateOut, <-chan error) {
+	states := make(chan StateOut)
+	errs := make(chan error, 1)
+
+	interval := c.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	go func() {
+		defer close(states)
+		defer close(errs)
+
+		var last *StateOut
+		failures := 0
+		for {
+			delay := interval
+			state, _, err := c.fetchTopic(ctx, topic)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				if isFatalWatchError(err) {
+					sendWatchError(errs, err, true)
+					return
+				}
+
+				failures++
+				delay = c.reconnectDelay(failures)
+				sendWatchError(errs, &ReconnectError{Err: err, Failures: failures, Delay: delay}, false)
+			} else {
+				failures = 0
+				if last == nil || stateChanged(*last, *state) {
+					select {
+					case states <- *state:
+						last = state
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return states, errs
+}
+
+//stateChanged returns true if the topic has moved to a different state, or
+// received a new event, between prev and next
+func stateChanged(prev, next StateOut) bool {
+	return prev.State != next.State ||
+		!prev.Last.ReportedAt.Equal(next.Last.ReportedAt) ||
+		!prev.Last.OccurredAt.Equal(next.Last.OccurredAt) ||
+		prev.Last.OK != next.Last.OK ||
+		prev.Last.Message != next.Last.Message
+}
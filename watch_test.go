@@ -0,0 +1,37 @@
+package shout_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	shout "github.com/thomasmitchell/go-shout"
+)
+
+func TestWatchDryRun(t *testing.T) {
+	client, server := newTestClient(t, respondWith(brokenStateJSON), shout.WithDryRun())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	states, errs := client.Watch(ctx, "my-topic")
+	for range states {
+		t.Errorf("Expected no states from a dry run")
+	}
+
+	err := <-errs
+	if !errors.Is(err, shout.ErrDryRun) {
+		t.Errorf("Expected ErrDryRun to stop watching, got %v", err)
+	}
+
+	var reconnect *shout.ReconnectError
+	if errors.As(err, &reconnect) {
+		t.Errorf("Expected ErrDryRun not to be retried, got %s", err)
+	}
+
+	if ctx.Err() != nil {
+		t.Errorf("Expected watching to stop before the context was done")
+	}
+}